@@ -2,9 +2,44 @@ package colors
 
 import (
 	"fmt"
+	"os"
 	"regexp"
+	"sync/atomic"
+
+	"golang.org/x/term"
 )
 
+// Color modes for SetMode.
+const (
+	ModeAuto   = "auto"
+	ModeAlways = "always"
+	ModeNever  = "never"
+)
+
+// disabled is set when colors are turned off, colors are on by default.
+var disabled atomic.Bool
+
+// SetMode enables or disables colored output. ModeAuto enables colors
+// if stdout is a terminal.
+func SetMode(mode string) error {
+	switch mode {
+	case ModeAlways:
+		disabled.Store(false)
+	case ModeNever:
+		disabled.Store(true)
+	case ModeAuto, "":
+		disabled.Store(!term.IsTerminal(int(os.Stdout.Fd())))
+	default:
+		return fmt.Errorf("invalid color mode %q (expected always, auto or never)", mode)
+	}
+	return nil
+}
+
+// Enabled returns true if colored output is enabled.
+func Enabled() bool {
+	return !disabled.Load()
+}
+
 // csiPattern matches ANSI CSI escape sequences.
 var csiPattern = regexp.MustCompile(`\x1b\[[?>]?[0-9;]*[A-Za-z]`)
 
@@ -35,62 +70,65 @@ const (
 )
 
 func colorize(color, text string) string {
+	if disabled.Load() {
+		return text
+	}
 	return color + text + colorReset
 }
 
 // BrightGreen returns text in bright green color.
 func BrightGreen(text string) string {
-	return colorBright + colorGreen + text + colorReset
+	return colorize(colorBright+colorGreen, text)
 }
 
 // Green returns text in green color.
 func Green(text string) string {
-	return colorGreen + text + colorReset
+	return colorize(colorGreen, text)
 }
 
 // BrightYellow returns text in bright yellow color.
 func BrightYellow(text string) string {
-	return colorBright + colorYellow + text + colorReset
+	return colorize(colorBright+colorYellow, text)
 }
 
 // BrightOrange returns text in bright orange color.
 func BrightOrange(text string) string {
-	return colorOrange + text + colorReset
+	return colorize(colorOrange, text)
 }
 
 // BrightCyan returns text in bright cyan color.
 func BrightCyan(text string) string {
-	return colorBright + colorCyan + text + colorReset
+	return colorize(colorBright+colorCyan, text)
 }
 
 // BrightMagenta returns text in bright magenta color.
 func BrightMagenta(text string) string {
-	return colorBright + colorMagenta + text + colorReset
+	return colorize(colorBright+colorMagenta, text)
 }
 
 // BrightRed returns text in bright red color.
 func BrightRed(text string) string {
-	return colorBright + colorRed + text + colorReset
+	return colorize(colorBright+colorRed, text)
 }
 
 // Dim returns text in dim color.
 func Dim(text string) string {
-	return colorDim + text + colorReset
+	return colorize(colorDim, text)
 }
 
 // BrightWhite returns text in bright white color.
 func BrightWhite(text string) string {
-	return colorBright + colorWhite + text + colorReset
+	return colorize(colorBright+colorWhite, text)
 }
 
 // White returns text in white color.
 func White(text string) string {
-	return colorWhite + text + colorReset
+	return colorize(colorWhite, text)
 }
 
 // Gray returns text in gray color.
 func Gray(text string) string {
-	return colorGray + text + colorReset
+	return colorize(colorGray, text)
 }
 
 // PrintHeader prints a header with bright cyan color.
@@ -0,0 +1,28 @@
+package colors
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/term"
+)
+
+func TestSetMode(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SetMode(ModeAlways)
+	})
+
+	assert.NoError(t, SetMode(ModeAlways))
+	assert.True(t, Enabled())
+	assert.Equal(t, "\033[1m\033[31mfail\033[0m", BrightRed("fail"))
+
+	assert.NoError(t, SetMode(ModeNever))
+	assert.False(t, Enabled())
+	assert.Equal(t, "fail", BrightRed("fail"))
+
+	assert.NoError(t, SetMode(ModeAuto))
+	assert.Equal(t, term.IsTerminal(int(os.Stdout.Fd())), Enabled())
+
+	assert.Error(t, SetMode("sometimes"))
+}
@@ -3,17 +3,22 @@ package main
 import (
 	"context"
 	"debug/buildinfo"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/titpetric/cli"
+	"golang.org/x/term"
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
 	"github.com/titpetric/atkins/runner"
 )
 
@@ -21,13 +26,66 @@ func NewCommand() *cli.Command {
 	var pipelineFile string
 	var job string
 	var listFlag bool
+	var listTasksFlag bool
+	var jsonSchema bool
+	var showCommands bool
+	var showIDs bool
+	var githubAnnotations bool
+	var envPassthrough []string
+	var failOnEmpty bool
+	var summary bool
+	var profile string
+	var strictEnv bool
+	var onFail string
+	var stepsFlag string
+	var showAllOutput bool
+	var collapse bool
+	var colorMode string
+	var jobArgs []string
+	var lintIgnore []string
+	var lintStrict bool
+	var notifyFlag bool
 	var lintFlag bool
+	var dryRun bool
+	var printEnv bool
+	var interactive bool
 	var debug bool
 	var logFile string
+	var logStream string
+	var onlyChanged bool
+	var baseRef string
+	var maxLogAge string
+	var substitutionTimeout time.Duration
+	var descLines bool
+	var jsonEvents bool
+	var verbose bool
+	var dumpResolved string
+	var dumpLogTree string
+	var planDuration string
+	var reportHTML string
+	var logOnFailure bool
+	var onlyFailedOutput bool
+	var explainSkips bool
+	var noDeps bool
+	var listFlat bool
+	var redactPatterns []string
+	var maxParallel int
+	var maxFailures int
+	var spinner string
+	var spinnerInterval time.Duration
+	var jobTimeout time.Duration
 	var versionFlag bool
 	var finalOutputOnly bool
 	var workingDirectory string
+	var treeStyle string
+	var listFormat string
+	var deadline time.Duration
+	var repeat int
+	var maxDepth int
+	var configFile string
+	var flags *pflag.FlagSet
 	var fileFlag *pflag.Flag
+	var githubAnnotationsFlag *pflag.Flag
 
 	return &cli.Command{
 		Name:    "run",
@@ -35,17 +93,82 @@ func NewCommand() *cli.Command {
 		Default: true,
 		Bind: func(fs *pflag.FlagSet) {
 			fs.StringVarP(&pipelineFile, "file", "f", "", "Path to pipeline file (auto-discovers .atkins.yml)")
+			fs.StringVar(&configFile, "config", "", "Path to a config file with defaults for timeout, max_parallel and color (default $XDG_CONFIG_HOME/atkins/config.yml)")
 			fs.StringVar(&job, "job", "", "Specific job to run")
+			fs.BoolVar(&interactive, "interactive", false, "Select the job to run from a menu if none is given and there is no default job")
 			fs.BoolVarP(&listFlag, "list", "l", false, "List pipeline jobs and dependencies")
+			fs.BoolVar(&verbose, "verbose", false, "Show step descriptions with --list")
+			fs.BoolVar(&listTasksFlag, "list-tasks", false, "List pipeline jobs labeled as [job], [task] or [nested]")
+			fs.BoolVar(&listFlat, "flat", false, "List only the root job names, one per line, with --list")
+			fs.StringArrayVar(&redactPatterns, "redact", nil, "Replace matches of this regular expression in output and logs with *** (repeatable)")
+			fs.StringVar(&spinner, "spinner", "none", "Animate running steps: none, dots, line or arrows")
+			fs.DurationVar(&spinnerInterval, "spinner-interval", 0, "Time between spinner frames (default depends on --spinner)")
+			fs.IntVar(&maxFailures, "max-failures", 0, "Cancel the run once this many detached jobs failed, jobs not started yet are skipped (0 for unlimited)")
+			fs.IntVar(&maxParallel, "max-parallel", 0, "Maximum number of detached jobs running at once, higher priority jobs start first (0 for unlimited)")
+			fs.BoolVar(&jsonSchema, "json-schema", false, "Print the JSON Schema of the pipeline file format, for editor validation")
+			fs.StringVar(&profile, "profile", "", "Also load <file>.<profile> after each env include file, e.g. .env.prod")
+			fs.BoolVar(&strictEnv, "strict-env", false, "Fail if an env include file is readable by group or others, instead of warning")
+			fs.BoolVar(&notifyFlag, "notify", false, "Show a desktop notification with the summary when the run completes")
+			fs.StringVar(&onFail, "on-fail", "", "Run this job once if the pipeline fails, with ${{ failed_job }} set (overrides on_failure_job)")
+			fs.BoolVar(&summary, "summary", false, "Print a one-line summary with step counts and duration to stderr")
+			fs.BoolVar(&failOnEmpty, "fail-on-empty", false, "Fail jobs where no step ran (all skipped or none defined)")
+			fs.StringSliceVar(&envPassthrough, "env-passthrough", nil, "Only pass these OS environment variables to commands (e.g. PATH,HOME)")
+			fs.BoolVar(&githubAnnotations, "github-annotations", false, "Print GitHub Actions annotations (default: on when GITHUB_ACTIONS=true)")
+			fs.BoolVar(&showAllOutput, "show-all-output", false, "Show output of passed steps, by default only failed step output is shown")
+			fs.BoolVar(&collapse, "collapse", false, "Collapse runs of passed or skipped steps into a single line, e.g. ✓ x47")
+			fs.BoolVar(&showIDs, "show-ids", false, "Show node IDs as used in the event log")
+			fs.BoolVar(&showCommands, "show-commands", false, "List the full command text of every step")
+			fs.BoolVar(&dryRun, "dry-run", false, "Print the resolved execution plan as JSON without running it")
+			fs.StringVar(&planDuration, "print-plan-duration", "", "Print the estimated duration of the job from the most recent event log of the pipeline matching this path or glob, without running it")
+			fs.BoolVar(&printEnv, "print-env", false, "Print the environment the job's commands run with to stderr, without running it")
 			fs.BoolVar(&lintFlag, "lint", false, "Lint pipeline for errors")
+			fs.BoolVar(&lintStrict, "lint-strict", false, "Fail --lint on warnings too, not only on errors")
+			fs.StringSliceVar(&lintIgnore, "lint-ignore", nil, "Suppress lint warnings by issue (e.g. shadowed-variable,unreachable-step)")
 			fs.BoolVar(&debug, "debug", false, "Print debug data")
 			fs.BoolVarP(&versionFlag, "version", "v", false, "Print version and build information")
 			fs.StringVar(&logFile, "log", "", "Log file path for command execution")
+			fs.BoolVar(&jsonEvents, "json-events", false, "Stream events as JSON lines to stdout instead of rendering the tree")
+			fs.StringVar(&dumpResolved, "dump-resolved", "", "Write the pipeline as loaded, with includes and depends_on resolved, to this YAML file")
+			fs.StringVar(&dumpLogTree, "dump-log-tree", "", "Print the run tree recorded in this --log file, without running the pipeline")
+			fs.StringVar(&reportHTML, "report-html", "", "Write the run tree as an HTML page to this file when the run finishes")
+			fs.BoolVar(&onlyFailedOutput, "only-failed-output", false, "Print the captured output of all failed steps, grouped by step ID, when the run fails")
+			fs.BoolVar(&explainSkips, "explain-skips", false, "Print every skipped step and why it was skipped when the run finishes")
+			fs.BoolVar(&logOnFailure, "log-on-failure", false, "Only write the --log file when the run failed")
+			fs.BoolVar(&noDeps, "no-deps", false, "Run only the selected job, assuming its dependencies completed")
+			fs.DurationVar(&jobTimeout, "job-timeout", runner.DefaultOptions().DefaultTimeout, "Timeout for jobs without their own timeout")
+			fs.StringVar(&logStream, "log-stream", "", "Stream events as NDJSON to this file while running")
+			fs.BoolVar(&onlyChanged, "only-changed", false, "Skip jobs and steps whose run_if_changed files are unchanged since the last success")
+			fs.StringVar(&maxLogAge, "max-log-age", "", "Prune files in .atkins older than this when the run starts, e.g. 7d (removed files are printed with --debug)")
+			fs.StringVar(&baseRef, "base-ref", "", "Git ref that --only-changed compares against (default: $ATKINS_BASE_REF, origin/main, then HEAD~1)")
 			fs.BoolVar(&finalOutputOnly, "final", false, "Only render final output without redrawing (no interactive tree)")
 			fs.StringVarP(&workingDirectory, "working-directory", "w", "", "Change to this directory before running")
+			fs.StringVar(&colorMode, "color", colors.ModeAuto, "Colored output: always, auto (if stdout is a terminal) or never")
+			fs.BoolVar(&descLines, "desc-lines", false, "Show job descriptions on a dimmed line below the job, instead of after the job name")
+			fs.StringVar(&treeStyle, "tree-style", "", "Tree drawing style: unicode or ascii (auto-detected from locale)")
+			fs.StringVar(&listFormat, "format", "", "Output format: tree, dot or json for --list, json for --dry-run")
+			fs.StringVar(&stepsFlag, "steps", "", "Only run these steps of the job by index, e.g. 1,3-5 (others are skipped)")
+			fs.DurationVar(&deadline, "deadline", 0, "Maximum total runtime, cancels all running jobs when exceeded (e.g. 30m)")
+			fs.DurationVar(&substitutionTimeout, "substitution-timeout", runner.DefaultSubstitutionTimeout, "Maximum runtime of each $(...) command substitution")
+			fs.IntVar(&maxDepth, "max-depth", runner.DefaultMaxTaskDepth, "Maximum depth of tasks invoking other tasks, guards against infinite recursion")
+			fs.IntVar(&repeat, "repeat", 1, "Run the pipeline this many times and report how many runs passed, to detect flaky steps")
+			flags = fs
 			fileFlag = fs.Lookup("file")
+			githubAnnotationsFlag = fs.Lookup("github-annotations")
 		},
 		Run: func(ctx context.Context, args []string) error {
+			// Apply defaults from the config file to flags that weren't given
+			config, err := loadConfig(configFile)
+			if err == nil {
+				err = config.Apply(flags)
+			}
+			if err != nil {
+				return fmt.Errorf("%s %v", colors.BrightRed("ERROR:"), err)
+			}
+
+			if err := colors.SetMode(colorMode); err != nil {
+				return fmt.Errorf("%s %v", colors.BrightRed("ERROR:"), err)
+			}
+
 			// Handle working directory first, before anything else
 			if workingDirectory != "" {
 				if err := os.Chdir(workingDirectory); err != nil {
@@ -55,29 +178,59 @@ func NewCommand() *cli.Command {
 
 			// Handle version flag
 			if versionFlag {
-				printVersionInfo()
+				printVersionInfo(versionPipelineFile(pipelineFile, fileFlag != nil && fileFlag.Changed))
+				return nil
+			}
+
+			// Print the pipeline schema, no pipeline file is needed
+			if jsonSchema {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(model.JSONSchema())
+			}
+
+			// Render a prior run from its event log, no pipeline file is needed
+			if dumpLogTree != "" {
+				err := runner.DumpLogTree(dumpLogTree, runner.ListOptions{
+					TreeStyle: treeStyle,
+					ShowIDs:   showIDs,
+				})
+				if err != nil {
+					return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+				}
 				return nil
 			}
 
+			if baseRef == "" {
+				baseRef = os.Getenv("ATKINS_BASE_REF")
+			}
+
+			// Enable GitHub annotations inside GitHub Actions unless set explicitly
+			if githubAnnotationsFlag == nil || !githubAnnotationsFlag.Changed {
+				githubAnnotations = os.Getenv("GITHUB_ACTIONS") == "true"
+			}
+
 			// Track if file was explicitly provided
 			fileExplicitlySet := fileFlag != nil && fileFlag.Changed
 
 			// Handle positional arguments
-			for _, arg := range args {
-				// Check if arg is a file that exists (shebang invocation)
-				if _, err := os.Stat(arg); err == nil {
-					pipelineFile = arg
-					fileExplicitlySet = true
-				} else if arg == "-l" {
-					listFlag = true
-				} else if job == "" {
-					// Treat as job name if not already set
-					job = arg
-				}
+			var file string
+			var list bool
+			file, job, jobArgs, list = positionalArgs(args, job)
+			if file != "" {
+				pipelineFile = file
+				fileExplicitlySet = true
+			}
+			if list {
+				listFlag = true
 			}
 
 			var absPath string
-			var err error
+
+			redactor, err := runner.NewRedactor(redactPatterns)
+			if err != nil {
+				return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+			}
 
 			if fileExplicitlySet {
 				// If -f/--file was explicitly provided, use it directly without changing workdir
@@ -110,28 +263,71 @@ func NewCommand() *cli.Command {
 				return fmt.Errorf("%s No pipelines found", colors.BrightRed("ERROR:"))
 			}
 
+			if dumpResolved != "" {
+				if err := runner.WriteResolved(dumpResolved, pipelines); err != nil {
+					return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+				}
+			}
+
 			// Handle lint mode
 			if lintFlag {
 				for _, pipeline := range pipelines {
 					linter := runner.NewLinter(pipeline)
-					lintErrors := linter.Lint()
-					if len(lintErrors) > 0 {
-						fmt.Printf("%s Pipeline '%s' has errors:\n", colors.BrightRed("✗"), pipeline.Name)
-						for _, lintErr := range lintErrors {
-							fmt.Printf("  %s: %s\n", lintErr.Job, lintErr.Detail)
-						}
-						os.Exit(1)
+					linter.Ignore(lintIgnore...)
+					issues := linter.Lint()
+					printLintIssues(pipeline.Name, issues)
+					if code := lintExitCode(issues, lintStrict); code != 0 {
+						os.Exit(code)
 					}
 				}
 				fmt.Printf("%s Pipeline '%s' is valid\n", colors.BrightGreen("✓"), pipelines[0].Name)
 				return nil
 			}
 
+			// Handle dry-run mode
+			if dryRun {
+				for _, pipeline := range pipelines {
+					if err := runner.DryRunPipeline(pipeline, job, listFormat); err != nil {
+						return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+					}
+				}
+				return nil
+			}
+
+			// Estimate the run duration from a prior event log
+			if planDuration != "" {
+				for _, pipeline := range pipelines {
+					if err := runner.PrintPlanDuration(os.Stdout, pipeline, job, planDuration); err != nil {
+						return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+					}
+				}
+				return nil
+			}
+
+			// Print the merged environment of the job
+			if printEnv {
+				for _, pipeline := range pipelines {
+					err := runner.PrintEnv(ctx, os.Stderr, pipeline, job, runner.PipelineOptions{
+						PipelineFile:        pipelineFile,
+						Profile:             profile,
+						StrictEnv:           strictEnv,
+						EnvPassthrough:      envPassthrough,
+						Args:                jobArgs,
+						Redactor:            redactor,
+						SubstitutionTimeout: substitutionTimeout,
+					})
+					if err != nil {
+						return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+					}
+				}
+				return nil
+			}
+
 			// Handle list mode
-			if listFlag {
+			if listFlag || listTasksFlag {
 				for _, pipeline := range pipelines {
 					linter := runner.NewLinter(pipeline)
-					lintErrors := linter.Lint()
+					lintErrors, _ := splitLintWarnings(linter.Lint())
 					if len(lintErrors) > 0 {
 						fmt.Printf("%s Pipeline '%s' has dependency errors:\n", colors.BrightRed("✗"), pipeline.Name)
 						for _, lintErr := range lintErrors {
@@ -145,7 +341,16 @@ func NewCommand() *cli.Command {
 						fmt.Printf("%s\n", string(b))
 					}
 
-					if err := runner.ListPipeline(pipeline); err != nil {
+					if err := runner.ListPipeline(pipeline, runner.ListOptions{
+						TreeStyle: treeStyle,
+						Format:    listFormat,
+						Kinds:     listTasksFlag,
+						Commands:  showCommands,
+						ShowIDs:   showIDs,
+						DescLines: descLines,
+						Verbose:   verbose,
+						Flat:      listFlat,
+					}); err != nil {
 						fmt.Printf("%s %s\n", "ERROR:", err)
 						os.Exit(1)
 					}
@@ -153,18 +358,112 @@ func NewCommand() *cli.Command {
 				return nil
 			}
 
+			// Select the job from a menu, if stdin is a terminal
+			if interactive && job == "" && !hasDefaultJob(pipelines[0]) && term.IsTerminal(int(os.Stdin.Fd())) {
+				job, err = selectJob(os.Stdin, os.Stderr, pipelines[0])
+				if err != nil {
+					return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+				}
+			}
+
+			// Select the steps to run
+			var steps runner.StepSelection
+			if stepsFlag != "" {
+				steps, err = runner.ParseStepSelection(stepsFlag)
+				if err != nil {
+					return fmt.Errorf("%s %s", colors.BrightRed("ERROR:"), err)
+				}
+				fmt.Fprintf(os.Stderr, "%s --steps skips the other steps, selected steps may depend on their results\n", colors.BrightYellow("!"))
+			}
+
+			if repeat < 1 {
+				return fmt.Errorf("%s --repeat must be at least 1", colors.BrightRed("ERROR:"))
+			}
+
+			var pruneAge time.Duration
+			if maxLogAge != "" {
+				pruneAge, err = runner.ParseMaxAge(maxLogAge)
+				if err != nil {
+					return fmt.Errorf("%s --max-log-age: %s", colors.BrightRed("ERROR:"), err)
+				}
+			}
+
+			// Bound the whole run by the deadline
+			if deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, deadline)
+				defer cancel()
+			}
+
 			// Run pipeline(s)
 			var exitCode int
 			var failedPipeline string
 
 			for _, pipeline := range pipelines {
-				err := runner.RunPipeline(ctx, pipeline, runner.PipelineOptions{
-					Job:          job,
-					LogFile:      logFile,
-					PipelineFile: pipelineFile,
-					Debug:        debug,
-					FinalOnly:    finalOutputOnly,
-				})
+				var onComplete func(int, *eventlog.RunSummary)
+				if notifyFlag {
+					onComplete = func(jobs int, summary *eventlog.RunSummary) {
+						notify(pipeline.Name, jobs, summary)
+					}
+				}
+
+				run := func(logFile, logStream string) error {
+					return runner.RunPipeline(ctx, pipeline, runner.PipelineOptions{
+						Job:          job,
+						LogFile:      logFile,
+						EventStream:  logStream,
+						OnlyChanged:  onlyChanged,
+						PipelineFile: pipelineFile,
+						Debug:        debug,
+						FinalOnly:    finalOutputOnly,
+						TreeStyle:    treeStyle,
+						ShowIDs:      showIDs,
+						FailOnEmpty:  failOnEmpty,
+						Summary:      summary,
+						Profile:      profile,
+						Steps:        steps,
+
+						EnvPassthrough:      envPassthrough,
+						GitHubAnnotations:   githubAnnotations,
+						ShowAllOutput:       showAllOutput,
+						Args:                jobArgs,
+						OnComplete:          onComplete,
+						StrictEnv:           strictEnv,
+						Collapse:            collapse,
+						OnFail:              onFail,
+						MaxDepth:            maxDepth,
+						BaseRef:             baseRef,
+						MaxLogAge:           pruneAge,
+						SubstitutionTimeout: substitutionTimeout,
+						DescLines:           descLines,
+						JSONEvents:          jsonEvents,
+						ReportHTML:          reportHTML,
+						LogOnFailure:        logOnFailure,
+						NoDeps:              noDeps,
+						JobTimeout:          jobTimeout,
+						Redactor:            redactor,
+						MaxParallel:         maxParallel,
+						Spinner:             spinner,
+						SpinnerInterval:     spinnerInterval,
+						OnlyFailedOutput:    onlyFailedOutput,
+						ExplainSkips:        explainSkips,
+						MaxFailures:         maxFailures,
+					})
+				}
+
+				// Repeat the run to detect flaky steps, each run logs to its own files
+				if repeat > 1 {
+					result := repeatRuns(ctx, repeat, func(iteration int) error {
+						return run(iterationFile(logFile, iteration), iterationFile(logStream, iteration))
+					})
+					fmt.Fprintf(os.Stderr, "%s: %s\n", pipeline.Name, result)
+					if len(result.Failed) > 0 || result.Runs < repeat {
+						os.Exit(1)
+					}
+					continue
+				}
+
+				err := run(logFile, logStream)
 				if err != nil {
 					exitCode = 1
 					failedPipeline = pipeline.Name
@@ -175,7 +474,7 @@ func NewCommand() *cli.Command {
 							fmt.Fprintf(os.Stderr, "\nAn error occurred in %q pipeline:\n\n", failedPipeline)
 							fmt.Fprintf(os.Stderr, "  Exit code: %d\n", errorLog.LastExitCode)
 							fmt.Fprintf(os.Stderr, "  Error output:\n")
-							for _, line := range strings.Split(errorLog.Output, "\n") {
+							for _, line := range strings.Split(redactor.Redact(errorLog.Output), "\n") {
 								if line != "" {
 									fmt.Fprintf(os.Stderr, "    %s\n", line)
 								}
@@ -184,7 +483,7 @@ func NewCommand() *cli.Command {
 						exitCode = errorLog.LastExitCode
 					} else {
 						fmt.Fprintf(os.Stderr, "\nAn error occurred in %q pipeline:\n", failedPipeline)
-						fmt.Fprintf(os.Stderr, "  %s\n", err.Error())
+						fmt.Fprintf(os.Stderr, "  %s\n", redactor.Redact(err.Error()))
 					}
 
 					if exitCode != 0 {
@@ -197,7 +496,20 @@ func NewCommand() *cli.Command {
 	}
 }
 
-func printVersionInfo() {
+// versionPipelineFile returns the pipeline file to fingerprint for --version,
+// or an empty string if none is found.
+func versionPipelineFile(pipelineFile string, explicit bool) string {
+	if explicit {
+		return pipelineFile
+	}
+	configPath, _, err := runner.DiscoverConfigFromCwd()
+	if err != nil {
+		return ""
+	}
+	return configPath
+}
+
+func printVersionInfo(pipelineFile string) {
 	fmt.Printf("atkins\n")
 	fmt.Printf("  Version:     %s\n", Version)
 
@@ -221,6 +533,13 @@ func printVersionInfo() {
 		fmt.Printf("  Modified:    true (dirty working tree)\n")
 	}
 
+	if pipelineFile != "" {
+		if pipelines, err := runner.LoadPipeline(pipelineFile); err == nil {
+			fmt.Printf("  Pipeline:    %s\n", pipelineFile)
+			fmt.Printf("  Fingerprint: sha256:%s\n", pipelines[0].Fingerprint)
+		}
+	}
+
 	exePath, err := os.Executable()
 	var bi *buildinfo.BuildInfo
 	if err == nil {
@@ -263,3 +582,68 @@ func printVersionInfo() {
 		}
 	}
 }
+
+// printLintIssues prints lint warnings in yellow, followed by errors in red.
+func printLintIssues(pipeline string, issues []runner.LintError) {
+	lintErrors, lintWarnings := splitLintWarnings(issues)
+	for _, lintWarn := range lintWarnings {
+		fmt.Printf("%s %s: %s\n", colors.BrightYellow("!"), lintWarn.Job, colors.BrightYellow(lintWarn.Detail))
+	}
+	if len(lintErrors) > 0 {
+		fmt.Printf("%s Pipeline '%s' has errors:\n", colors.BrightRed("✗"), pipeline)
+		for _, lintErr := range lintErrors {
+			fmt.Printf("  %s: %s\n", lintErr.Job, colors.BrightRed(lintErr.Detail))
+		}
+	}
+}
+
+// positionalArgs splits the positional arguments into a pipeline file,
+// the job name and the job args. The first arg that isn't an existing
+// file or -l is the job name, unless job is set with --job, and the
+// args after it are passed to the job.
+func positionalArgs(args []string, job string) (file, jobName string, jobArgs []string, list bool) {
+	jobName = job
+	positionalJob := false
+	for _, arg := range args {
+		if positionalJob {
+			// Arguments after the job name are passed to the job as args
+			jobArgs = append(jobArgs, arg)
+		} else if _, err := os.Stat(arg); err == nil {
+			// Check if arg is a file that exists (shebang invocation)
+			file = arg
+		} else if arg == "-l" {
+			list = true
+		} else if jobName == "" {
+			// Treat as job name if not already set
+			jobName = arg
+			positionalJob = true
+		} else {
+			// The job is set with --job, the rest are its args
+			jobArgs = append(jobArgs, arg)
+		}
+	}
+	return file, jobName, jobArgs, list
+}
+
+// lintExitCode returns 1 if the lint issues contain errors, or with
+// strict set, warnings. Otherwise it returns 0.
+func lintExitCode(issues []runner.LintError, strict bool) int {
+	for _, issue := range issues {
+		if !issue.IsWarning() || strict {
+			return 1
+		}
+	}
+	return 0
+}
+
+// splitLintWarnings separates lint warnings from errors.
+func splitLintWarnings(all []runner.LintError) (errs, warnings []runner.LintError) {
+	for _, lintErr := range all {
+		if lintErr.IsWarning() {
+			warnings = append(warnings, lintErr)
+			continue
+		}
+		errs = append(errs, lintErr)
+	}
+	return errs, warnings
+}
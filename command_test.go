@@ -8,6 +8,8 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
 )
 
 func TestWorkingDirectory_ChangesDirectory(t *testing.T) {
@@ -85,3 +87,35 @@ func TestWorkingDirectory_EmptyIsNoOp(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, tmpDir, currentDir)
 }
+
+func TestLintExitCode(t *testing.T) {
+	warning := runner.LintError{Job: "a", Severity: runner.SeverityWarning}
+	failure := runner.LintError{Job: "b", Severity: runner.SeverityError}
+
+	assert.Equal(t, 0, lintExitCode(nil, false))
+	assert.Equal(t, 0, lintExitCode(nil, true))
+	assert.Equal(t, 0, lintExitCode([]runner.LintError{warning}, false))
+	assert.Equal(t, 1, lintExitCode([]runner.LintError{warning}, true))
+	assert.Equal(t, 1, lintExitCode([]runner.LintError{warning, failure}, false))
+}
+
+func TestPositionalArgs(t *testing.T) {
+	dir := t.TempDir()
+	pipeline := filepath.Join(dir, "pipeline.yml")
+	require.NoError(t, os.WriteFile(pipeline, []byte("name: test\n"), 0o644))
+
+	file, job, jobArgs, list := positionalArgs([]string{pipeline, "build", "a", pipeline}, "")
+	assert.Equal(t, pipeline, file)
+	assert.Equal(t, "build", job)
+	assert.Equal(t, []string{"a", pipeline}, jobArgs, "args after the job name go to the job")
+	assert.False(t, list)
+
+	// With --job, a file arg still loads the pipeline
+	file, job, jobArgs, _ = positionalArgs([]string{pipeline, "a"}, "build")
+	assert.Equal(t, pipeline, file)
+	assert.Equal(t, "build", job)
+	assert.Equal(t, []string{"a"}, jobArgs)
+
+	_, _, _, list = positionalArgs([]string{"-l"}, "")
+	assert.True(t, list)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Config holds org-wide defaults from the global config file. They apply
+// to flags not set on the command line, fields set in the pipeline take
+// precedence as they do over flag defaults.
+type Config struct {
+	Timeout     string `yaml:"timeout,omitempty"`      // Timeout for jobs without their own, e.g. 10m
+	MaxParallel int    `yaml:"max_parallel,omitempty"` // Maximum number of detached jobs running at once
+	Color       string `yaml:"color,omitempty"`        // Colored output: always, auto or never
+}
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/atkins/config.yml,
+// falling back to ~/.config/atkins/config.yml.
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "atkins", "config.yml")
+}
+
+// loadConfig reads the config file at path, or the default path if empty.
+// A missing default config file is not an error.
+func loadConfig(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	config := &Config{}
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return config, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Apply sets the flags not given on the command line to the config values.
+func (c *Config) Apply(flags *pflag.FlagSet) error {
+	values := map[string]string{
+		"job-timeout": c.Timeout,
+		"color":       c.Color,
+	}
+	if c.MaxParallel != 0 {
+		values["max-parallel"] = strconv.Itoa(c.MaxParallel)
+	}
+
+	for name, value := range values {
+		flag := flags.Lookup(name)
+		if value == "" || flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("invalid config %s %q: %w", name, value, err)
+		}
+	}
+	return nil
+}
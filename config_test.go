@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	// A missing default config is fine
+	config, err := loadConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, &Config{}, config)
+
+	// An explicit config must exist
+	_, err = loadConfig(filepath.Join(dir, "missing.yml"))
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "atkins", "config.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("timeout: 10m\nmax_parallel: 4\ncolor: never\n"), 0o644))
+
+	config, err = loadConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Timeout: "10m", MaxParallel: 4, Color: "never"}, config)
+}
+
+func TestConfig_Apply(t *testing.T) {
+	config := &Config{Timeout: "10m", MaxParallel: 4, Color: "never"}
+
+	t.Run("defaults", func(t *testing.T) {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		NewCommand().Bind(fs)
+		require.NoError(t, fs.Parse(nil))
+
+		require.NoError(t, config.Apply(fs))
+		assert.Equal(t, "10m0s", fs.Lookup("job-timeout").Value.String())
+		assert.Equal(t, "4", fs.Lookup("max-parallel").Value.String())
+		assert.Equal(t, "never", fs.Lookup("color").Value.String())
+	})
+
+	t.Run("flags override", func(t *testing.T) {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		NewCommand().Bind(fs)
+		require.NoError(t, fs.Parse([]string{"--job-timeout", "1m", "--max-parallel", "2"}))
+
+		require.NoError(t, config.Apply(fs))
+		assert.Equal(t, "1m0s", fs.Lookup("job-timeout").Value.String())
+		assert.Equal(t, "2", fs.Lookup("max-parallel").Value.String())
+		assert.Equal(t, "never", fs.Lookup("color").Value.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		NewCommand().Bind(fs)
+		require.NoError(t, fs.Parse(nil))
+
+		err := (&Config{Timeout: "soon"}).Apply(fs)
+		assert.ErrorContains(t, err, "job-timeout")
+	})
+}
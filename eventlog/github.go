@@ -0,0 +1,76 @@
+package eventlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteGitHubAnnotations writes events as GitHub Actions workflow commands.
+// The events of each job are wrapped in a ::group::, and failed events
+// are reported as ::error:: annotations against file.
+func WriteGitHubAnnotations(w io.Writer, file string, events []*Event) error {
+	var (
+		order  []string
+		byName = make(map[string][]*Event)
+	)
+	for _, event := range events {
+		name := eventJobName(event.ID)
+		if _, ok := byName[name]; !ok {
+			order = append(order, name)
+		}
+		byName[name] = append(byName[name], event)
+	}
+
+	var sb strings.Builder
+	for _, name := range order {
+		fmt.Fprintf(&sb, "::group::%s\n", escapeGitHubData(name))
+		for _, event := range byName[name] {
+			fmt.Fprintf(&sb, "%s %s\n", event.Result, event.Run)
+			writeGitHubOutput(&sb, event.Stdout)
+			writeGitHubOutput(&sb, event.Stderr)
+			if event.Result == ResultFail {
+				message := event.Error
+				if message == "" {
+					message = "failed"
+				}
+				fmt.Fprintf(&sb, "::error file=%s,title=%s::%s\n",
+					escapeGitHubProperty(file), escapeGitHubProperty(event.ID), escapeGitHubData(message))
+			}
+		}
+		sb.WriteString("::endgroup::\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// eventJobName returns the job name from an event ID like `jobs.build.steps.0`.
+func eventJobName(id string) string {
+	name := strings.TrimPrefix(id, "jobs.")
+	if idx := strings.Index(name, ".steps."); idx >= 0 {
+		name = name[:idx]
+	}
+	return name
+}
+
+// writeGitHubOutput writes captured command output, ensuring a trailing newline.
+func writeGitHubOutput(sb *strings.Builder, output string) {
+	if output == "" {
+		return
+	}
+	sb.WriteString(output)
+	if !strings.HasSuffix(output, "\n") {
+		sb.WriteString("\n")
+	}
+}
+
+// escapeGitHubData escapes the message of a workflow command.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,218 @@
+package eventlog
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+//go:embed report.html
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportHTML))
+
+// htmlReport is the data rendered by the report template.
+type htmlReport struct {
+	Title   string
+	Summary *RunSummary
+	Root    *htmlNode
+}
+
+// htmlNode is a job, step or iteration in the report.
+type htmlNode struct {
+	Name     string
+	ID       string
+	Status   string
+	Icon     string
+	Duration float64
+	Error    string
+	Output   template.HTML
+	Open     bool
+	Leaf     bool
+	Children []*htmlNode
+}
+
+// WriteHTMLReport writes the run tree as a self-contained HTML page with
+// collapsible nodes. Failed nodes are expanded and show their error and
+// captured output, with ANSI colors converted to styled spans.
+func WriteHTMLReport(w io.Writer, title string, state *StateNode, summary *RunSummary, events []*Event) error {
+	byID := make(map[string]*Event, len(events))
+	for _, event := range events {
+		byID[event.ID] = event
+	}
+
+	root := newHTMLNode(state, byID)
+	if root == nil {
+		root = &htmlNode{}
+	}
+	return reportTemplate.Execute(w, htmlReport{
+		Title:   title,
+		Summary: summary,
+		Root:    root,
+	})
+}
+
+// newHTMLNode converts a state node and its children for the report.
+func newHTMLNode(state *StateNode, events map[string]*Event) *htmlNode {
+	if state == nil {
+		return nil
+	}
+
+	node := &htmlNode{
+		Name:     colors.StripANSI(state.Name),
+		ID:       state.ID,
+		Status:   state.Status,
+		Duration: state.Duration,
+		Leaf:     len(state.Children) == 0,
+	}
+
+	// The tree may not be updated yet when a run fails, the event result is final
+	if event := events[state.ID]; event != nil && event.Result == ResultFail {
+		node.Status = "failed"
+		node.Error = event.Error
+		node.Output = ansiToHTML(strings.TrimRight(event.Stdout+event.Stderr, "\n"))
+		node.Open = true
+		node.Leaf = node.Leaf && node.Error == "" && node.Output == ""
+	}
+
+	switch node.Status {
+	case "passed":
+		node.Icon = "✓"
+	case "failed":
+		node.Icon = "✗"
+	case "skipped":
+		node.Icon = "⊘"
+	default:
+		node.Icon = "●"
+	}
+
+	var failedChild bool
+	for _, child := range state.Children {
+		childNode := newHTMLNode(child, events)
+		node.Children = append(node.Children, childNode)
+		failedChild = failedChild || childNode.Open
+	}
+
+	// The error is shown on the failed step, not again on its job
+	if failedChild {
+		node.Open = true
+		node.Error = ""
+	}
+	return node
+}
+
+var sgrPattern = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// ansiToHTML escapes s and converts SGR color sequences to styled spans.
+// Other escape sequences are removed.
+func ansiToHTML(s string) template.HTML {
+	var (
+		sb    strings.Builder
+		style ansiStyle
+		open  bool
+		last  int
+	)
+	text := func(s string) {
+		sb.WriteString(html.EscapeString(colors.StripANSI(s)))
+	}
+
+	for _, match := range sgrPattern.FindAllStringSubmatchIndex(s, -1) {
+		text(s[last:match[0]])
+		last = match[1]
+
+		style.apply(s[match[2]:match[3]])
+		if open {
+			sb.WriteString("</span>")
+			open = false
+		}
+		if css := style.css(); css != "" {
+			fmt.Fprintf(&sb, `<span style="%s">`, css)
+			open = true
+		}
+	}
+	text(s[last:])
+	if open {
+		sb.WriteString("</span>")
+	}
+	return template.HTML(sb.String())
+}
+
+// ansiPalette holds the 16 standard terminal colors.
+var ansiPalette = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+// ansiStyle is the text style set by SGR sequences.
+type ansiStyle struct {
+	bold  bool
+	dim   bool
+	color string
+}
+
+// apply updates the style with the parameters of an SGR sequence, e.g. `1;31`.
+func (a *ansiStyle) apply(params string) {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i]) // empty is 0, a reset
+		switch {
+		case code == 0:
+			*a = ansiStyle{}
+		case code == 1:
+			a.bold = true
+		case code == 2:
+			a.dim = true
+		case code == 22:
+			a.bold, a.dim = false, false
+		case code >= 30 && code <= 37:
+			a.color = ansiPalette[code-30]
+		case code >= 90 && code <= 97:
+			a.color = ansiPalette[code-90+8]
+		case code == 39:
+			a.color = ""
+		case code == 38 && i+2 < len(codes) && codes[i+1] == "5":
+			n, _ := strconv.Atoi(codes[i+2])
+			a.color = xterm256(n)
+			i += 2
+		}
+	}
+}
+
+// css returns the inline CSS for the style, empty for the default style.
+func (a ansiStyle) css() string {
+	var css []string
+	if a.color != "" {
+		css = append(css, "color:"+a.color)
+	}
+	if a.bold {
+		css = append(css, "font-weight:bold")
+	}
+	if a.dim {
+		css = append(css, "opacity:.7")
+	}
+	return strings.Join(css, ";")
+}
+
+// xterm256 returns the hex color of an xterm 256 color index.
+func xterm256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return ansiPalette[n]
+	case n < 232:
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	default:
+		gray := 8 + 10*(n-232)
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
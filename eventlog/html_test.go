@@ -0,0 +1,71 @@
+package eventlog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	state := &StateNode{
+		Name:   "pipeline",
+		Status: "failed",
+		Children: []*StateNode{
+			{
+				Name:   "build",
+				ID:     "jobs.build",
+				Status: "passed",
+				Children: []*StateNode{
+					{Name: "run: go build", ID: "jobs.build.steps.0", Status: "passed", Duration: 1.5},
+				},
+			},
+			{
+				Name:   "test",
+				ID:     "jobs.test",
+				Status: "running",
+				Children: []*StateNode{
+					{Name: "run: go test <pkg>", ID: "jobs.test.steps.0", Status: "failed"},
+				},
+			},
+		},
+	}
+	events := []*Event{
+		{ID: "jobs.build.steps.0", Result: ResultPass, Stdout: "built"},
+		{ID: "jobs.test.steps.0", Result: ResultFail, Error: "exit status 1", Stdout: "\033[31mFAIL\033[0m <TestX>\n"},
+		{ID: "jobs.test", Result: ResultFail, Error: "exit status 1"},
+	}
+	summary := &RunSummary{Result: ResultFail, TotalSteps: 2, PassedSteps: 1, FailedSteps: 1}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTMLReport(&buf, "ci <main>", state, summary, events))
+	out := buf.String()
+
+	assert.Contains(t, out, "<title>ci &lt;main&gt;</title>")
+	assert.Contains(t, out, "1 passed, 1 failed, 0 skipped of 2 steps")
+	for _, id := range []string{"jobs.build", "jobs.build.steps.0", "jobs.test", "jobs.test.steps.0"} {
+		assert.Contains(t, out, `data-id="`+id+`"`)
+	}
+	assert.Contains(t, out, `<span class="duration">1.50s</span>`)
+
+	// Failed nodes are expanded, show the error once and colored output
+	assert.Contains(t, out, `<details class="node failed" data-id="jobs.test" open>`)
+	assert.Contains(t, out, `<details class="node failed" data-id="jobs.test.steps.0" open>`)
+	assert.Contains(t, out, `run: go test &lt;pkg&gt;`)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte(`<pre class="error">exit status 1</pre>`)))
+	assert.Contains(t, out, `<pre class="output"><span style="color:#cd3131">FAIL</span> &lt;TestX&gt;</pre>`)
+	assert.NotContains(t, out, "built", "output of passed steps is not shown")
+	assert.Contains(t, out, `<details class="node passed leaf" data-id="jobs.build.steps.0">`)
+}
+
+func TestAnsiToHTML(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain <text>":                   "plain &lt;text&gt;",
+		"\033[1;32mok\033[0m done":       `<span style="color:#0dbc79;font-weight:bold">ok</span> done`,
+		"\033[38;5;208morange\033[39m":   `<span style="color:#ff8700">orange</span>`,
+		"\033[90mgray\033[m\033[2K\rnew": `<span style="color:#666666">gray</span>` + "\rnew",
+	} {
+		assert.Equal(t, want, string(ansiToHTML(in)), in)
+	}
+}
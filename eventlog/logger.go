@@ -2,6 +2,9 @@ package eventlog
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
@@ -36,6 +39,15 @@ type Logger struct {
 	events    []*Event
 	startTime time.Time
 	debug     bool
+
+	// stream receives each event as a JSON line as soon as it's logged.
+	stream io.Writer
+
+	// onlyOnFailure defers the file write to failed runs.
+	onlyOnFailure bool
+
+	// redact scrubs error messages and output before they're logged.
+	redact func(string) string
 }
 
 // NewLogger creates a new event logger.
@@ -44,7 +56,24 @@ func NewLogger(filePath, pipelineName, pipelineFile string, debug bool) *Logger
 	if filePath == "" {
 		return nil
 	}
+	return newLogger(filePath, pipelineName, pipelineFile, debug)
+}
+
+// NewStreamLogger creates an event logger that only streams events as
+// NDJSON to w. The final Write is a no-op unless a file path is set.
+func NewStreamLogger(w io.Writer, pipelineName, pipelineFile string, debug bool) *Logger {
+	l := newLogger("", pipelineName, pipelineFile, debug)
+	l.stream = w
+	return l
+}
+
+// NewMemoryLogger creates an event logger that only collects events,
+// for consumers of GetEvents. The final Write is a no-op.
+func NewMemoryLogger(pipelineName, pipelineFile string, debug bool) *Logger {
+	return newLogger("", pipelineName, pipelineFile, debug)
+}
 
+func newLogger(filePath, pipelineName, pipelineFile string, debug bool) *Logger {
 	now := time.Now()
 	runID := ulid.Make().String()
 
@@ -70,8 +99,56 @@ func NewLogger(filePath, pipelineName, pipelineFile string, debug bool) *Logger
 	}
 }
 
+// SetFingerprint records the pipeline file fingerprint in the run metadata.
+func (l *Logger) SetFingerprint(fingerprint string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.metadata.Fingerprint = fingerprint
+}
+
+// SetStream sets a writer that receives each event as a JSON line
+// immediately when it's logged.
+func (l *Logger) SetStream(w io.Writer) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stream = w
+}
+
+// SetWriteOnFailure makes Write skip the file for passed runs. The
+// events are still collected, so a failed run writes the full log.
+func (l *Logger) SetWriteOnFailure(onlyOnFailure bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onlyOnFailure = onlyOnFailure
+}
+
+// SetRedact sets a function applied to the command, error and output
+// of each event before it's logged, e.g. to scrub secrets.
+func (l *Logger) SetRedact(redact func(string) string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redact = redact
+}
+
 // LogExec logs a single execution event (one per exec).
 func (l *Logger) LogExec(result Result, id, run string, start float64, durationMs int64, err error) {
+	l.LogExecOutput(result, id, run, start, durationMs, err, "", "")
+}
+
+// LogExecOutput logs a single execution event with the captured stdout and stderr.
+func (l *Logger) LogExecOutput(result Result, id, run string, start float64, durationMs int64, err error, stdout, stderr string) {
 	if l == nil {
 		return
 	}
@@ -82,6 +159,10 @@ func (l *Logger) LogExec(result Result, id, run string, start float64, durationM
 	if err != nil {
 		errMsg = err.Error()
 	}
+	if l.redact != nil {
+		run, errMsg = l.redact(run), l.redact(errMsg)
+		stdout, stderr = l.redact(stdout), l.redact(stderr)
+	}
 
 	event := &Event{
 		ID:       id,
@@ -90,11 +171,19 @@ func (l *Logger) LogExec(result Result, id, run string, start float64, durationM
 		Start:    start,
 		Duration: float64(durationMs) / 1000.0,
 		Error:    errMsg,
+		Stdout:   stdout,
+		Stderr:   stderr,
 	}
 	if l.debug {
 		event.GoroutineID = getGoroutineID()
 	}
 	l.events = append(l.events, event)
+
+	if l.stream != nil {
+		if data, err := json.Marshal(event); err == nil {
+			_, _ = l.stream.Write(append(data, '\n'))
+		}
+	}
 }
 
 // elapsed returns seconds since the logger started.
@@ -110,6 +199,17 @@ func (l *Logger) Write(state *StateNode, summary *RunSummary) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.filePath == "" {
+		return nil
+	}
+	if l.onlyOnFailure && summary != nil && summary.Result != ResultFail {
+		return nil
+	}
+
+	if l.redact != nil {
+		RedactState(state, l.redact)
+	}
+
 	log := &Log{
 		Metadata: l.metadata,
 		State:    state,
@@ -125,6 +225,31 @@ func (l *Logger) Write(state *StateNode, summary *RunSummary) error {
 	return os.WriteFile(l.filePath, data, 0o644)
 }
 
+// RedactState scrubs the node names and conditions of the state tree in place.
+func RedactState(node *StateNode, redact func(string) string) {
+	if node == nil {
+		return
+	}
+	node.Name, node.If = redact(node.Name), redact(node.If)
+	for _, child := range node.Children {
+		RedactState(child, redact)
+	}
+}
+
+// ReadLog reads a log written by Logger.Write.
+func ReadLog(filePath string) (*Log, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &Log{}
+	if err := yaml.Unmarshal(data, log); err != nil {
+		return nil, fmt.Errorf("parse event log %s: %w", filePath, err)
+	}
+	return log, nil
+}
+
 // GetStartTime returns the start time of the run.
 func (l *Logger) GetStartTime() time.Time {
 	if l == nil {
@@ -1,7 +1,11 @@
 package eventlog
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -83,6 +87,44 @@ func TestLogger_LogExec_Skip(t *testing.T) {
 	assert.Equal(t, 0.0, events[0].Duration)
 }
 
+func TestLogger_LogExecOutput(t *testing.T) {
+	tmpFile := "test_output.yml"
+	defer os.Remove(tmpFile)
+
+	logger := NewLogger(tmpFile, "test-pipeline", "test.yml", false)
+	require.NotNil(t, logger)
+
+	logger.LogExecOutput(ResultPass, "jobs.test-job.steps.0", "make", 0, 10, nil, "built\n", "warning\n")
+
+	events := logger.GetEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, "built\n", events[0].Stdout)
+	assert.Equal(t, "warning\n", events[0].Stderr)
+}
+
+func TestLogger_Stream(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStreamLogger(&buf, "test-pipeline", "test.yml", false)
+	require.NotNil(t, logger)
+
+	logger.LogExec(ResultPass, "jobs.test.steps.0", "echo one", 0, 10, nil)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	logger.LogExec(ResultFail, "jobs.test.steps.1", "exit 1", 0.1, 10, errors.New("boom"))
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, "jobs.test.steps.1", event.ID)
+	assert.Equal(t, ResultFail, event.Result)
+	assert.Equal(t, "boom", event.Error)
+
+	// Without a file path, the final write is a no-op
+	assert.NoError(t, logger.Write(nil, nil))
+}
+
 func TestLogger_NilSafe(t *testing.T) {
 	var logger *Logger
 
@@ -187,3 +229,27 @@ func TestGetGoroutineID(t *testing.T) {
 	id := getGoroutineID()
 	assert.Greater(t, id, uint64(0))
 }
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	logger := NewMemoryLogger("test-pipeline", "atkins.yml", false)
+	logger.LogExecOutput(ResultPass, "jobs.build.steps.0", "go build", 0, 10, nil, "built\n", "")
+	logger.LogExecOutput(ResultFail, "jobs.test.steps.0", "go test", 0, 10, errors.New("exit status 1\nFAIL"), "", "--- FAIL: TestX")
+	logger.LogExec(ResultPass, "jobs.build.steps.1", "go vet", 0, 10, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteGitHubAnnotations(&buf, "ci/atkins.yml", logger.GetEvents()))
+
+	assert.Equal(t, strings.Join([]string{
+		"::group::build",
+		"pass go build",
+		"built",
+		"pass go vet",
+		"::endgroup::",
+		"::group::test",
+		"fail go test",
+		"--- FAIL: TestX",
+		"::error file=ci/atkins.yml,title=jobs.test.steps.0::exit status 1%0AFAIL",
+		"::endgroup::",
+		"",
+	}, "\n"), buf.String())
+}
@@ -49,6 +49,49 @@ func NodeToStateNode(node *treeview.Node) *StateNode {
 	return state
 }
 
+// labelToStatus converts a Status label back to a treeview.Status.
+func labelToStatus(label string) treeview.Status {
+	switch label {
+	case "running":
+		return treeview.StatusRunning
+	case "passed":
+		return treeview.StatusPassed
+	case "failed":
+		return treeview.StatusFailed
+	case "skipped":
+		return treeview.StatusSkipped
+	case "conditional":
+		return treeview.StatusConditional
+	default:
+		return treeview.StatusPending
+	}
+}
+
+// StateNodeToNode converts a StateNode back to a treeview.Node for rendering.
+// It is the inverse of NodeToStateNode.
+func StateNodeToNode(state *StateNode) *treeview.Node {
+	if state == nil {
+		return nil
+	}
+
+	node := treeview.NewNode(state.Name)
+	node.ID = state.ID
+	node.Status = labelToStatus(state.Status)
+	node.If = state.If
+	node.CreatedAt = state.CreatedAt
+	node.UpdatedAt = state.UpdatedAt
+	node.StartOffset = state.Start
+	node.Duration = state.Duration
+
+	for _, childState := range state.Children {
+		if child := StateNodeToNode(childState); child != nil {
+			node.AddChild(child)
+		}
+	}
+
+	return node
+}
+
 // TreeNodeToStateNode converts a treeview.TreeNode to a StateNode.
 func TreeNodeToStateNode(node *treeview.TreeNode) *StateNode {
 	if node == nil {
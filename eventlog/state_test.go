@@ -51,6 +51,31 @@ func TestNodeToStateNode_WithChildren(t *testing.T) {
 	assert.Equal(t, ResultFail, state.Children[1].Result)
 }
 
+func TestStateNodeToNode(t *testing.T) {
+	root := treeview.NewNode("root")
+	root.SetStatus(treeview.StatusFailed)
+	root.SetDuration(1.5)
+	for _, status := range []treeview.Status{treeview.StatusPassed, treeview.StatusSkipped, treeview.StatusConditional} {
+		child := treeview.NewNode(status.Label())
+		child.ID = "jobs." + status.Label()
+		child.SetStatus(status)
+		child.SetStartOffset(0.25)
+		root.AddChild(child)
+	}
+
+	state := NodeToStateNode(root)
+	node := StateNodeToNode(state)
+
+	assert.Equal(t, treeview.StatusFailed, node.Status)
+	assert.Equal(t, 1.5, node.Duration)
+	assert.Len(t, node.Children, 3)
+	assert.Equal(t, treeview.StatusSkipped, node.Children[1].Status)
+	assert.Equal(t, "jobs.skipped", node.Children[1].ID)
+	assert.Equal(t, 0.25, node.Children[1].StartOffset)
+	assert.Equal(t, state, NodeToStateNode(node))
+	assert.Nil(t, StateNodeToNode(nil))
+}
+
 func TestCountSteps(t *testing.T) {
 	root := &StateNode{
 		Name:   "root",
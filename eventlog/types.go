@@ -14,13 +14,15 @@ const (
 
 // Event represents a single execution event in the log (one per exec).
 type Event struct {
-	ID          string  `yaml:"id"`
-	Run         string  `yaml:"run"`
-	Result      Result  `yaml:"result"`
-	Start       float64 `yaml:"start"`                  // Seconds since run started
-	Duration    float64 `yaml:"duration"`               // Seconds
-	Error       string  `yaml:"error,omitempty"`        // Only for fail events
-	GoroutineID uint64  `yaml:"goroutine_id,omitempty"` // Only when debug is enabled
+	ID          string  `yaml:"id" json:"id"`
+	Run         string  `yaml:"run" json:"run"`
+	Result      Result  `yaml:"result" json:"result"`
+	Start       float64 `yaml:"start" json:"start"`                                   // Seconds since run started
+	Duration    float64 `yaml:"duration" json:"duration"`                             // Seconds
+	Error       string  `yaml:"error,omitempty" json:"error,omitempty"`               // Only for fail events
+	Stdout      string  `yaml:"stdout,omitempty" json:"stdout,omitempty"`             // Captured command stdout
+	Stderr      string  `yaml:"stderr,omitempty" json:"stderr,omitempty"`             // Captured command stderr
+	GoroutineID uint64  `yaml:"goroutine_id,omitempty" json:"goroutine_id,omitempty"` // Only when debug is enabled
 }
 
 // StateNode represents a node in the execution state tree for YAML output.
@@ -40,12 +42,13 @@ type StateNode struct {
 
 // RunMetadata contains information about the execution environment.
 type RunMetadata struct {
-	RunID      string    `yaml:"run_id"`
-	CreatedAt  time.Time `yaml:"created_at"`
-	Pipeline   string    `yaml:"pipeline,omitempty"`
-	File       string    `yaml:"file,omitempty"`
-	ModulePath string    `yaml:"module_path,omitempty"`
-	Git        *GitInfo  `yaml:"git,omitempty"`
+	RunID       string    `yaml:"run_id"`
+	CreatedAt   time.Time `yaml:"created_at"`
+	Pipeline    string    `yaml:"pipeline,omitempty"`
+	File        string    `yaml:"file,omitempty"`
+	Fingerprint string    `yaml:"fingerprint,omitempty"`
+	ModulePath  string    `yaml:"module_path,omitempty"`
+	Git         *GitInfo  `yaml:"git,omitempty"`
 }
 
 // GitInfo contains git repository information.
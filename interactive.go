@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+)
+
+// hasDefaultJob returns true if the pipeline has a default job.
+func hasDefaultJob(pipeline *model.Pipeline) bool {
+	_, ok := runner.PipelineJobs(pipeline)["default"]
+	return ok
+}
+
+// selectJob lists the root jobs of pipeline with their descriptions on w,
+// and reads the selected job, by number or by name, from r. The jobs are
+// the same as listed with --list --flat.
+func selectJob(r io.Reader, w io.Writer, pipeline *model.Pipeline) (string, error) {
+	jobs := runner.RootJobNames(pipeline)
+	if len(jobs) == 0 {
+		return "", errors.New("no jobs to select from")
+	}
+
+	all := runner.PipelineJobs(pipeline)
+	for i, name := range jobs {
+		fmt.Fprintf(w, "%3d) %s", i+1, colors.BrightOrange(name))
+		if desc := all[name].Desc; desc != "" {
+			fmt.Fprintf(w, " %s", colors.Gray(desc))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprint(w, "Select a job: ")
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no job selected: %w", err)
+	}
+
+	choice := strings.TrimSpace(line)
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(jobs) {
+		return jobs[n-1], nil
+	}
+	if slices.Contains(jobs, choice) {
+		return choice, nil
+	}
+	return "", fmt.Errorf("invalid job selection %q", choice)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/model"
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestSelectJob(t *testing.T) {
+	hidden := false
+	pipeline := &model.Pipeline{
+		Jobs: map[string]*model.Job{
+			"build":      {Desc: "Build the binary"},
+			"test":       {Desc: "Run tests"},
+			"test:unit":  {},
+			"release":    {Show: &hidden},
+			"deploy":     {},
+			"build:docs": {},
+		},
+	}
+
+	var out bytes.Buffer
+	job, err := selectJob(strings.NewReader("2\n"), &out, pipeline)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", job)
+
+	menu := colors.StripANSI(out.String())
+	assert.Contains(t, menu, "1) build Build the binary")
+	assert.Contains(t, menu, "2) deploy")
+	assert.Contains(t, menu, "3) test Run tests")
+	assert.NotContains(t, menu, "test:unit")
+	assert.NotContains(t, menu, "release")
+	assert.Equal(t, []string{"build", "deploy", "test"}, runner.RootJobNames(pipeline), "same jobs as --list --flat")
+
+	job, err = selectJob(strings.NewReader("test"), &out, pipeline)
+	require.NoError(t, err)
+	assert.Equal(t, "test", job)
+
+	_, err = selectJob(strings.NewReader("4\n"), &out, pipeline)
+	assert.ErrorContains(t, err, `invalid job selection "4"`)
+
+	_, err = selectJob(strings.NewReader(""), &out, pipeline)
+	assert.ErrorContains(t, err, "no job selected")
+
+	assert.False(t, hasDefaultJob(pipeline))
+	pipeline.Jobs["default"] = &model.Job{}
+	assert.True(t, hasDefaultJob(pipeline))
+}
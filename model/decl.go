@@ -13,13 +13,30 @@ type Decl struct {
 	Vars    map[string]any `yaml:"vars,omitempty"`
 	Include *IncludeDecl   `yaml:"include,omitempty"`
 	Env     *EnvDecl       `yaml:"env,omitempty"`
+
+	// Cache memoizes $(...) command substitutions for the duration of a run.
+	// Identical commands are executed once and the output is reused.
+	Cache bool `yaml:"cache,omitempty"`
 }
 
 // EnvDecl represents an environment variable declaration that can contain
 // both manually-set variables and includes from external files.
-type EnvDecl Decl
+type EnvDecl struct {
+	Decl `yaml:",inline"`
+
+	// Import lists env files loaded when the scope starts, used to hand
+	// off env between jobs, e.g. a file written by a dependency. Paths
+	// are interpolated and imported values override included ones.
+	Import *IncludeDecl `yaml:"import,omitempty"`
+}
 
 // IncludeDecl represents file includes that can be either a single string or a list of strings.
+//
+// Paths are interpolated before loading, e.g. `config/${{ stage }}.yml`.
+// Includes load before the vars of their own scope, so only the OS env
+// and the vars and env of enclosing scopes are available. A pipeline
+// include can use the OS env and ${{ args }}, a job include can also use
+// pipeline vars, and so on.
 type IncludeDecl struct {
 	Files []string
 }
@@ -44,3 +61,11 @@ func (e *IncludeDecl) UnmarshalYAML(node *yaml.Node) error {
 
 	return fmt.Errorf("invalid include format: expected string or list of strings, got %v", node.Kind)
 }
+
+// MarshalYAML encodes a single file as a string and multiple files as a list.
+func (e IncludeDecl) MarshalYAML() (any, error) {
+	if len(e.Files) == 1 {
+		return e.Files[0], nil
+	}
+	return e.Files, nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Detach controls if a job or step runs in the background. It's set with
+// `detach: true`, `false` or an expression, e.g. `detach: "${{ CI == 'true' }}"`,
+// which is evaluated when the job or step runs.
+type Detach struct {
+	Enabled bool
+	Expr    string
+}
+
+// IsSet returns true if detach is enabled or depends on an expression.
+func (d Detach) IsSet() bool {
+	return d.Enabled || d.Expr != ""
+}
+
+// UnmarshalYAML implements custom unmarshalling for `detach`,
+// taking a boolean value or an expression string.
+func (d *Detach) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("invalid detach value: expected a boolean or an expression")
+	}
+	*d = Detach{}
+	if node.Tag == "!!bool" {
+		return node.Decode(&d.Enabled)
+	}
+	d.Expr = node.Value
+	return nil
+}
+
+// MarshalYAML encodes detach as a boolean value or the expression.
+func (d Detach) MarshalYAML() (any, error) {
+	if d.Expr != "" {
+		return d.Expr, nil
+	}
+	return d.Enabled, nil
+}
@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestDetach_UnmarshalYAML(t *testing.T) {
+	for value, want := range map[string]model.Detach{
+		"true":                       {Enabled: true},
+		"false":                      {},
+		`"${{ CI == 'true' }}"`:      {Expr: "${{ CI == 'true' }}"},
+		`"${{ parallel ?? false }}"`: {Expr: "${{ parallel ?? false }}"},
+	} {
+		var job model.Job
+		assert.NoError(t, yaml.Unmarshal([]byte("steps: [echo]\ndetach: "+value), &job))
+		assert.Equal(t, want, job.Detach, value)
+		assert.Equal(t, value != "false", job.Detach.IsSet(), value)
+
+		out, err := yaml.Marshal(job.Detach)
+		assert.NoError(t, err)
+		var decoded model.Detach
+		assert.NoError(t, yaml.Unmarshal(out, &decoded))
+		assert.Equal(t, want, decoded, value)
+	}
+
+	var step model.Step
+	assert.Error(t, yaml.Unmarshal([]byte("run: echo\ndetach: [true]"), &step))
+}
@@ -6,26 +6,49 @@ import (
 	yaml "gopkg.in/yaml.v3"
 )
 
+// Timeout modes for jobs with retries.
+const (
+	TimeoutModeAttempt = "attempt"
+	TimeoutModeTotal   = "total"
+)
+
+// Workspace modes for jobs running in an isolated directory.
+const (
+	WorkspaceCopy    = "copy"
+	WorkspaceSymlink = "symlink"
+)
+
 // Job represents a job/task in the pipeline.
 type Job struct {
-	*Decl
+	*Decl `yaml:",inline"`
 
-	Desc      string       `yaml:"desc,omitempty"`
-	RunsOn    string       `yaml:"runs_on,omitempty"`
-	Container string       `yaml:"container,omitempty"`
-	If        string       `yaml:"if,omitempty"`
-	Cmd       string       `yaml:"cmd,omitempty"`
-	Cmds      []*Step      `yaml:"cmds,omitempty"`
-	Run       string       `yaml:"run,omitempty"`
-	Steps     []*Step      `yaml:"steps,omitempty"`
-	Detach    bool         `yaml:"detach,omitempty"`
-	Show      *bool        `yaml:"show,omitempty"` // Show in display (true=show, false=hide, nil=show if root level/ invoked)
-	DependsOn Dependencies `yaml:"depends_on,omitempty"`
-	Requires  []string     `yaml:"requires,omitempty"` // Variables required when invoked in a loop
-	Timeout   string       `yaml:"timeout,omitempty"`  // e.g., "10m", "300s"
-	Summarize bool         `yaml:"summarize,omitempty"`
-	Passthru  bool         `yaml:"passthru,omitempty"` // If true, output is printed with tree indentation
-	TTY       bool         `yaml:"tty,omitempty"`      // If true, allocate a PTY for all steps (enables color output)
+	Desc         string         `yaml:"desc,omitempty"`
+	Group        string         `yaml:"group,omitempty"` // Group name for organizing jobs in listings
+	RunsOn       string         `yaml:"runs_on,omitempty"`
+	Container    string         `yaml:"container,omitempty"`
+	If           string         `yaml:"if,omitempty"`
+	Cmd          string         `yaml:"cmd,omitempty"`
+	Cmds         []*Step        `yaml:"cmds,omitempty"`
+	Run          string         `yaml:"run,omitempty"`
+	Steps        []*Step        `yaml:"steps,omitempty"`
+	Detach       Detach         `yaml:"detach,omitempty"`
+	Show         *bool          `yaml:"show,omitempty"` // Show in display (true=show, false=hide, nil=show if root level/ invoked)
+	DependsOn    Dependencies   `yaml:"depends_on,omitempty"`
+	Requires     []string       `yaml:"requires,omitempty"`       // Variables required when invoked in a loop
+	Outputs      map[string]any `yaml:"outputs,omitempty"`        // Values exported to dependent jobs as needs.<job>.outputs
+	RunIfChanged []string       `yaml:"run_if_changed,omitempty"` // Globs; with --only-changed the job is skipped if no match changed since the last success
+	IfExists     []string       `yaml:"if_exists,omitempty"`      // Globs relative to the pipeline dir; the job is skipped if any has no match
+	Timeout      string         `yaml:"timeout,omitempty"`        // e.g., "10m", "300s"
+	Retries      int            `yaml:"retries,omitempty"`        // Number of times to rerun a failed job
+	RetryDelay   string         `yaml:"retry_delay,omitempty"`    // Delay before the first retry, doubled for each further retry
+	RetryBudget  int            `yaml:"retry_budget,omitempty"`   // Total number of retries for failing steps, shared by all steps of the job
+	TimeoutMode  string         `yaml:"timeout_mode,omitempty"`   // Apply timeout per "attempt" (default) or to the "total" of all attempts
+	Summarize    bool           `yaml:"summarize,omitempty"`
+	Passthru     Passthru       `yaml:"passthru,omitempty"`    // If true, output is printed with tree indentation, auto only on a terminal
+	TTY          bool           `yaml:"tty,omitempty"`         // If true, allocate a PTY for all steps (enables color output)
+	Workspace    string         `yaml:"workspace,omitempty"`   // Run in an isolated temp dir: "copy" or "symlink" of the project
+	StrictBash   *bool          `yaml:"strict_bash,omitempty"` // Default strict_bash for the job steps
+	Priority     int            `yaml:"priority,omitempty"`    // Detached jobs with a higher priority start first when --max-parallel is reached
 
 	Name   string `yaml:"-"`
 	Nested bool   `yaml:"-"`
@@ -72,7 +95,7 @@ func (j *Job) UnmarshalYAML(node *yaml.Node) error {
 		cmd := strings.TrimSpace(node.Value)
 		j.Desc = cmd // Use command as description for simple tasks
 		j.Steps = []*Step{{Run: cmd, Name: cmd, HidePrefix: true}}
-		j.Passthru = true
+		j.Passthru = PassthruOn
 		return nil
 	}
 
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Passthru modes, set with `passthru: true`, `false` or `auto`.
+const (
+	PassthruOff Passthru = iota
+	PassthruOn
+	PassthruAuto
+)
+
+// Passthru controls if command output is printed with tree indentation.
+// PassthruAuto only prints output when attached to a terminal.
+type Passthru int
+
+// Enabled returns true if output should be passed through. The terminal
+// argument tells if the output is attached to a terminal.
+func (p Passthru) Enabled(terminal bool) bool {
+	return p == PassthruOn || (p == PassthruAuto && terminal)
+}
+
+// UnmarshalYAML implements custom unmarshalling for `passthru`,
+// taking a boolean value or "auto".
+func (p *Passthru) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Value == "auto" {
+		*p = PassthruAuto
+		return nil
+	}
+
+	var enabled bool
+	if err := node.Decode(&enabled); err != nil {
+		return fmt.Errorf("invalid passthru value %q: expected true, false or auto", node.Value)
+	}
+	*p = PassthruOff
+	if enabled {
+		*p = PassthruOn
+	}
+	return nil
+}
+
+// MarshalYAML encodes the mode as a boolean value or "auto".
+func (p Passthru) MarshalYAML() (any, error) {
+	if p == PassthruAuto {
+		return "auto", nil
+	}
+	return p == PassthruOn, nil
+}
@@ -0,0 +1,37 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestPassthru_UnmarshalYAML(t *testing.T) {
+	for value, want := range map[string]model.Passthru{
+		"true":  model.PassthruOn,
+		"false": model.PassthruOff,
+		"auto":  model.PassthruAuto,
+	} {
+		var step model.Step
+		assert.NoError(t, yaml.Unmarshal([]byte("run: echo\npassthru: "+value), &step))
+		assert.Equal(t, want, step.Passthru, value)
+
+		out, err := yaml.Marshal(step.Passthru)
+		assert.NoError(t, err)
+		assert.Equal(t, value+"\n", string(out))
+	}
+
+	var step model.Step
+	assert.Error(t, yaml.Unmarshal([]byte("run: echo\npassthru: always"), &step))
+}
+
+func TestPassthru_Enabled(t *testing.T) {
+	assert.True(t, model.PassthruOn.Enabled(false))
+	assert.True(t, model.PassthruOn.Enabled(true))
+	assert.False(t, model.PassthruOff.Enabled(true))
+	assert.False(t, model.PassthruAuto.Enabled(false))
+	assert.True(t, model.PassthruAuto.Enabled(true))
+}
@@ -6,11 +6,38 @@ import (
 
 // Pipeline represents the root structure of an atkins.yml file.
 type Pipeline struct {
-	*Decl
+	*Decl `yaml:",inline"`
 
-	Name  string          `yaml:"name,omitempty"`
-	Jobs  map[string]*Job `yaml:"jobs,omitempty"`
-	Tasks map[string]*Job `yaml:"tasks,omitempty"`
+	Name     string          `yaml:"name,omitempty"`
+	Jobs     map[string]*Job `yaml:"jobs,omitempty"`
+	Tasks    map[string]*Job `yaml:"tasks,omitempty"`
+	Requires []string        `yaml:"requires,omitempty"` // Variables or env required before any job runs
+	PostRun  []*Step         `yaml:"post_run,omitempty"` // Steps run once after all jobs, pass or fail
+
+	// BeforeAll steps run once before any job, AfterAll steps run once
+	// after all jobs, pass or fail. A failure in either fails the pipeline.
+	BeforeAll []*Step `yaml:"before_all,omitempty"`
+	AfterAll  []*Step `yaml:"after_all,omitempty"`
+
+	// RequiresTools lists executables that must be on PATH before any job runs.
+	RequiresTools []string `yaml:"requires_tools,omitempty"`
+
+	// OnFailureJob is a job run once if the pipeline fails,
+	// with the first failed job available as ${{ failed_job }}.
+	OnFailureJob string `yaml:"on_failure_job,omitempty"`
+
+	// Snippets are named commands, used by steps with use_snippet.
+	Snippets map[string]string `yaml:"snippets,omitempty"`
+
+	// EnvPassthrough restricts the OS environment visible to commands to
+	// these variables. Declared env is always passed.
+	EnvPassthrough []string `yaml:"env_passthrough,omitempty"`
+
+	// Fingerprint is the SHA-256 of the pipeline file, set when loaded.
+	Fingerprint string `yaml:"-"`
+
+	// Dir is the directory of the pipeline file, set when loaded.
+	Dir string `yaml:"-"`
 }
 
 // UnmarshalYAML implements custom unmarshalling for Pipeline to handle Decl.
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaID is the identifier of the pipeline JSON Schema.
+const JSONSchemaID = "https://github.com/titpetric/atkins/atkins.schema.json"
+
+// JSONSchema returns a JSON Schema describing the pipeline file format,
+// derived from the yaml tags of the model structs. Jobs and steps may
+// also be given as a plain command string, and include, depends_on and
+// needs take a string or a list of strings.
+func JSONSchema() map[string]any {
+	s := &schemaBuilder{
+		defs: make(map[string]any),
+	}
+
+	root := s.object(reflect.TypeOf(Pipeline{}))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = JSONSchemaID
+	root["title"] = "atkins pipeline"
+	root["$defs"] = s.defs
+	return root
+}
+
+// schemaBuilder collects the definitions of named types, so recursive
+// types like Step and EnvDecl are referenced instead of expanded.
+type schemaBuilder struct {
+	defs map[string]any
+}
+
+// object returns the schema of a struct, with embedded structs inlined.
+// Unknown keys are allowed, as they are ignored when loading a pipeline.
+func (s *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	s.properties(t, properties)
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// properties adds the yaml fields of t to properties.
+func (s *schemaBuilder) properties(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			s.properties(indirect(field.Type), properties)
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		properties[name] = s.schema(field.Type)
+	}
+}
+
+// schema returns the schema for a field type.
+func (s *schemaBuilder) schema(t reflect.Type) map[string]any {
+	t = indirect(t)
+
+	switch t {
+	case reflect.TypeOf(IncludeDecl{}), reflect.TypeOf(Dependencies{}):
+		return stringOrList()
+	case reflect.TypeOf(PassthruOff):
+		return map[string]any{
+			"oneOf": []any{
+				map[string]any{"type": "boolean"},
+				map[string]any{"enum": []any{"auto"}},
+			},
+		}
+	case reflect.TypeOf(Detach{}):
+		return map[string]any{
+			"oneOf": []any{
+				map[string]any{"type": "boolean"},
+				map[string]any{"type": "string"},
+			},
+		}
+	case reflect.TypeOf(Job{}):
+		return s.ref(t, func() map[string]any {
+			return stringOr(s.object(t))
+		})
+	case reflect.TypeOf(Step{}):
+		return s.ref(t, func() map[string]any {
+			step := s.object(t)
+			step["properties"].(map[string]any)["defer"] = s.schema(t)
+			return stringOr(step)
+		})
+	case reflect.TypeOf(EnvDecl{}):
+		return s.ref(t, func() map[string]any {
+			return s.object(t)
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{
+			"type":  "array",
+			"items": s.schema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": s.schema(t.Elem()),
+		}
+	case reflect.Struct:
+		return s.object(t)
+	}
+
+	// Values of any type, e.g. vars
+	return map[string]any{}
+}
+
+// ref registers the definition of a named type once and references it.
+func (s *schemaBuilder) ref(t reflect.Type, build func() map[string]any) map[string]any {
+	name := t.Name()
+	if _, ok := s.defs[name]; !ok {
+		// Register first, so recursive references resolve to the definition
+		s.defs[name] = nil
+		s.defs[name] = build()
+	}
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+// stringOr allows a plain string in place of the object schema.
+func stringOr(object map[string]any) map[string]any {
+	return map[string]any{
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			object,
+		},
+	}
+}
+
+// stringOrList allows a string or a list of strings.
+func stringOrList() map[string]any {
+	return map[string]any{
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			},
+		},
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
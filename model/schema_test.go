@@ -0,0 +1,135 @@
+package model_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+func TestJSONSchema_ValidPipelines(t *testing.T) {
+	files, err := filepath.Glob("../tests/*.yml")
+	assert.NoError(t, err)
+	files = append(files, "../.atkins.yml", "../ci/release.yml", "../ci/examples.yml")
+
+	schema := loadSchema(t)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+
+		var doc any
+		assert.NoError(t, yaml.Unmarshal(data, &doc))
+		assert.NoError(t, validateSchema(schema, schema, doc, "$"), file)
+	}
+}
+
+func TestJSONSchema_InvalidPipeline(t *testing.T) {
+	schema := loadSchema(t)
+
+	for _, content := range []string{
+		"jobs:\n  default:\n    steps: echo hi\n",
+		"jobs:\n  default:\n    steps:\n      - run: echo hi\n        passthru: yes please\n",
+		"jobs:\n  default:\n    depends_on: {build: true}\n",
+		"jobs:\n  default:\n    passthru: always\n",
+	} {
+		var doc any
+		assert.NoError(t, yaml.Unmarshal([]byte(content), &doc))
+		assert.Error(t, validateSchema(schema, schema, doc, "$"), content)
+	}
+}
+
+// loadSchema round trips the schema through JSON, like an editor reads it.
+func loadSchema(t *testing.T) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(model.JSONSchema())
+	assert.NoError(t, err)
+
+	var schema map[string]any
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, model.JSONSchemaID, schema["$id"])
+	return schema
+}
+
+// validateSchema checks doc against the subset of JSON Schema that JSONSchema produces.
+func validateSchema(root, schema map[string]any, doc any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := root["$defs"].(map[string]any)[strings.TrimPrefix(ref, "#/$defs/")]
+		return validateSchema(root, def.(map[string]any), doc, path)
+	}
+
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		var lastErr error
+		for _, option := range oneOf {
+			if err := validateSchema(root, option.(map[string]any), doc, path); err != nil {
+				lastErr = err
+				continue
+			}
+			matched++
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d of oneOf: %v", path, matched, lastErr)
+		}
+		return nil
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		if !slices.Contains(enum, doc) {
+			return fmt.Errorf("%s: %v is not one of %v", path, doc, enum)
+		}
+		return nil
+	}
+
+	switch schema["type"] {
+	case "string":
+		if _, ok := doc.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", path, doc)
+		}
+	case "boolean":
+		if _, ok := doc.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", path, doc)
+		}
+	case "integer":
+		if _, ok := doc.(int); !ok {
+			return fmt.Errorf("%s: expected integer, got %T", path, doc)
+		}
+	case "array":
+		items, ok := doc.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", path, doc)
+		}
+		for idx, item := range items {
+			if err := validateSchema(root, schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", path, idx)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := doc.(map[string]any)
+		if !ok {
+			if doc == nil {
+				return nil
+			}
+			return fmt.Errorf("%s: expected object, got %T", path, doc)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for key, value := range object {
+			property, ok := properties[key].(map[string]any)
+			if !ok {
+				property, _ = schema["additionalProperties"].(map[string]any)
+			}
+			if err := validateSchema(root, property, value, path+"."+key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -9,25 +9,37 @@ import (
 
 // Step represents a step within a job.
 type Step struct {
-	*Decl
-
-	Name       string                 `yaml:"name,omitempty"`
-	Desc       string                 `yaml:"desc,omitempty"`
-	Run        string                 `yaml:"run,omitempty"`
-	Cmd        string                 `yaml:"cmd,omitempty"`
-	Cmds       []string               `yaml:"cmds,omitempty"`
-	Task       string                 `yaml:"task,omitempty"` // Task/job name to invoke
-	If         string                 `yaml:"if,omitempty"`
-	For        string                 `yaml:"for,omitempty"`
-	Uses       string                 `yaml:"uses,omitempty"`
-	With       map[string]interface{} `yaml:"with,omitempty"`
-	Detach     bool                   `yaml:"detach,omitempty"`
-	Deferred   bool                   `yaml:"deferred,omitempty"`
-	Verbose    bool                   `yaml:"verbose,omitempty"`
-	Summarize  bool                   `yaml:"summarize,omitempty"`
-	Passthru   bool                   `yaml:"passthru,omitempty"` // If true, output is printed with tree indentation
-	TTY        bool                   `yaml:"tty,omitempty"`      // If true, allocate a PTY for the command (enables color output)
-	HidePrefix bool                   `yaml:"-"`                  // If true, don't show "run:" prefix in display
+	*Decl `yaml:",inline"`
+
+	ID            string                 `yaml:"id,omitempty"` // Step id, referenced by the needs of later steps
+	Name          string                 `yaml:"name,omitempty"`
+	Desc          string                 `yaml:"desc,omitempty"`
+	Run           string                 `yaml:"run,omitempty"`
+	Cmd           string                 `yaml:"cmd,omitempty"`
+	Cmds          []string               `yaml:"cmds,omitempty"`
+	Stdin         string                 `yaml:"stdin,omitempty"`      // Input fed to the commands, interpolated
+	StdinFrom     string                 `yaml:"stdin_from,omitempty"` // File fed to the commands as stdin, interpolated, relative to the pipeline file
+	Task          string                 `yaml:"task,omitempty"`       // Task/job name to invoke
+	Needs         Dependencies           `yaml:"needs,omitempty"`      // Ids of earlier steps to wait for, e.g. with detached steps
+	If            string                 `yaml:"if,omitempty"`
+	For           string                 `yaml:"for,omitempty"`
+	IterLabel     string                 `yaml:"label,omitempty"`          // Tree label for each for loop iteration, interpolated with the loop vars
+	FailThreshold string                 `yaml:"fail_threshold,omitempty"` // For loop iterations allowed to fail before the step fails, a count or a percentage, e.g. 3 or 10%
+	RunIfChanged  []string               `yaml:"run_if_changed,omitempty"` // Globs; with --only-changed the step is skipped if no match changed since the last success
+	UseSnippet    string                 `yaml:"use_snippet,omitempty"`    // Pipeline snippet to run as the command, interpolated in the step context
+	Parallel      []*Step                `yaml:"parallel,omitempty"`       // Steps run concurrently, the step finishes when all of them did
+	Uses          string                 `yaml:"uses,omitempty"`
+	With          map[string]interface{} `yaml:"with,omitempty"`
+	Continue      bool                   `yaml:"continue,omitempty"`    // If true, cmds keep running after a failing command
+	AsLabel       bool                   `yaml:"as_label,omitempty"`    // If true, the first line of output is the label, the rest is kept as output
+	StrictBash    *bool                  `yaml:"strict_bash,omitempty"` // Run with `set -euo pipefail`, on by default for multi-line scripts
+	Detach        Detach                 `yaml:"detach,omitempty"`
+	Deferred      bool                   `yaml:"deferred,omitempty"`
+	Verbose       bool                   `yaml:"verbose,omitempty"`
+	Summarize     bool                   `yaml:"summarize,omitempty"`
+	Passthru      Passthru               `yaml:"passthru,omitempty"` // If true, output is printed with tree indentation, auto only on a terminal
+	TTY           bool                   `yaml:"tty,omitempty"`      // If true, allocate a PTY for the command (enables color output)
+	HidePrefix    bool                   `yaml:"-"`                  // If true, don't show "run:" prefix in display
 }
 
 // DeferredStep represents a deferred step wrapper.
@@ -40,6 +52,10 @@ func (s *Step) String() string {
 	switch {
 	case s.Task != "":
 		return "task: " + s.Task
+	case s.UseSnippet != "":
+		return "snippet: " + s.UseSnippet
+	case len(s.Parallel) > 0:
+		return fmt.Sprintf("parallel: <%d steps>", len(s.Parallel))
 	case s.Run != "":
 		// If Run contains newlines, display as <script> instead of full command
 		if strings.Contains(s.Run, "\n") {
@@ -60,6 +76,10 @@ func (s *Step) DisplayLabel() string {
 	switch {
 	case s.Task != "":
 		return "task: " + s.Task
+	case s.UseSnippet != "":
+		return "snippet: " + s.UseSnippet
+	case len(s.Parallel) > 0:
+		return fmt.Sprintf("parallel: <%d steps>", len(s.Parallel))
 	case s.Run != "":
 		// If Run contains newlines, display as <script> instead of full command
 		if strings.Contains(s.Run, "\n") {
@@ -85,6 +105,18 @@ func (s *Step) Label(showPrefix bool) *Label {
 			Type:       "task",
 			ShowPrefix: showPrefix && !s.HidePrefix,
 		}
+	case s.UseSnippet != "":
+		return &Label{
+			Text:       s.UseSnippet,
+			Type:       "snippet",
+			ShowPrefix: showPrefix && !s.HidePrefix,
+		}
+	case len(s.Parallel) > 0:
+		return &Label{
+			Text:       fmt.Sprintf("<%d steps>", len(s.Parallel)),
+			Type:       "parallel",
+			ShowPrefix: showPrefix && !s.HidePrefix,
+		}
 	case s.Run != "":
 		text := s.Run
 		if strings.Contains(text, "\n") {
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/runner"
+)
+
+// notify shows a desktop notification with the run summary.
+// If no notifier is available, a warning is printed instead.
+func notify(pipeline string, jobs int, summary *eventlog.RunSummary) {
+	title := "atkins: " + pipeline + " passed"
+	if summary.Result == eventlog.ResultFail {
+		title = "atkins: " + pipeline + " failed"
+	}
+	message := strings.TrimPrefix(colors.StripANSI(runner.FormatSummary(jobs, summary)), "atkins: ")
+
+	args := notifyCommand(runtime.GOOS, exec.LookPath, title, message)
+	if args == nil {
+		fmt.Fprintf(os.Stderr, "%s --notify: no desktop notifier found on %s\n", colors.BrightYellow("!"), runtime.GOOS)
+		return
+	}
+
+	if err := exec.Command(args[0], args[1:]...).Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s --notify: %s failed: %v\n", colors.BrightYellow("!"), args[0], err)
+	}
+}
+
+// notifyCommand returns the command line showing a desktop notification on
+// goos, using notify-send on Linux and terminal-notifier or osascript on macOS.
+// It returns nil if no notifier is available.
+func notifyCommand(goos string, lookPath func(string) (string, error), title, message string) []string {
+	available := func(name string) bool {
+		_, err := lookPath(name)
+		return err == nil
+	}
+
+	switch goos {
+	case "linux":
+		if available("notify-send") {
+			return []string{"notify-send", title, message}
+		}
+	case "darwin":
+		if available("terminal-notifier") {
+			return []string{"terminal-notifier", "-title", title, "-message", message}
+		}
+		if available("osascript") {
+			script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+			return []string{"osascript", "-e", script}
+		}
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
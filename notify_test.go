@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyCommand(t *testing.T) {
+	lookPath := func(available ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, tool := range available {
+				if tool == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	assert.Equal(t,
+		[]string{"notify-send", "atkins: ci passed", "1 job, 2 steps"},
+		notifyCommand("linux", lookPath("notify-send"), "atkins: ci passed", "1 job, 2 steps"),
+	)
+	assert.Equal(t,
+		[]string{"terminal-notifier", "-title", "atkins: ci passed", "-message", "1 job, 2 steps"},
+		notifyCommand("darwin", lookPath("terminal-notifier", "osascript"), "atkins: ci passed", "1 job, 2 steps"),
+	)
+	assert.Equal(t,
+		[]string{"osascript", "-e", `display notification "say \"hi\"" with title "atkins: ci failed"`},
+		notifyCommand("darwin", lookPath("osascript"), "atkins: ci failed", `say "hi"`),
+	)
+
+	assert.Nil(t, notifyCommand("linux", lookPath(), "title", "message"))
+	assert.Nil(t, notifyCommand("windows", lookPath("notify-send"), "title", "message"))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+// repeatResult is the aggregate result of a pipeline run repeated with --repeat.
+type repeatResult struct {
+	Runs   int
+	Failed []int // 1-based iterations that failed
+}
+
+// String returns a summary like `passed 4/5 (failed: 3)`.
+func (r *repeatResult) String() string {
+	passed := fmt.Sprintf("passed %d/%d", r.Runs-len(r.Failed), r.Runs)
+	if len(r.Failed) == 0 {
+		return colors.BrightGreen(passed)
+	}
+
+	failed := make([]string, 0, len(r.Failed))
+	for _, iteration := range r.Failed {
+		failed = append(failed, strconv.Itoa(iteration))
+	}
+	return colors.BrightRed(passed) + " (failed: " + strings.Join(failed, ", ") + ")"
+}
+
+// repeatRuns calls run n times with the 1-based iteration, reporting failed
+// iterations as they happen. It stops early if ctx is cancelled.
+func repeatRuns(ctx context.Context, n int, run func(iteration int) error) *repeatResult {
+	result := &repeatResult{}
+	for iteration := 1; iteration <= n; iteration++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result.Runs++
+		if err := run(iteration); err != nil {
+			result.Failed = append(result.Failed, iteration)
+			fmt.Fprintf(os.Stderr, "%s iteration %d/%d failed: %v\n", colors.BrightYellow("!"), iteration, n, err)
+		}
+	}
+	return result
+}
+
+// iterationFile suffixes filename with the iteration, e.g. `run.yml` to `run.2.yml`.
+// An empty filename is returned as is.
+func iterationFile(filename string, iteration int) string {
+	if filename == "" {
+		return ""
+	}
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(filename, ext), iteration, ext)
+}
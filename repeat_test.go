@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func TestRepeatRuns(t *testing.T) {
+	var calls []int
+	result := repeatRuns(context.Background(), 5, func(iteration int) error {
+		calls = append(calls, iteration)
+		if iteration == 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, calls)
+	assert.Equal(t, 5, result.Runs)
+	assert.Equal(t, []int{3}, result.Failed)
+	assert.Equal(t, "passed 4/5 (failed: 3)", colors.StripANSI(result.String()))
+}
+
+func TestRepeatRuns_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := repeatRuns(ctx, 5, func(iteration int) error {
+		if iteration == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.Equal(t, 2, result.Runs)
+	assert.Equal(t, "passed 2/2", colors.StripANSI(result.String()))
+}
+
+func TestIterationFile(t *testing.T) {
+	assert.Equal(t, "", iterationFile("", 1))
+	assert.Equal(t, "run.2.yml", iterationFile("run.yml", 2))
+	assert.Equal(t, "logs/events.3", iterationFile("logs/events", 3))
+}
@@ -0,0 +1,55 @@
+package runner
+
+import "sync"
+
+// CommandCache memoizes the output of $(...) command substitutions within a run.
+// It's keyed by the interpolated command string.
+type CommandCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+// NewCommandCache creates a new, empty CommandCache.
+func NewCommandCache() *CommandCache {
+	return &CommandCache{
+		entries: make(map[string]string),
+	}
+}
+
+// Get returns the cached output for a command, if present.
+func (c *CommandCache) Get(cmd string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output, ok := c.entries[cmd]
+	return output, ok
+}
+
+// Set stores the output for a command.
+func (c *CommandCache) Set(cmd, output string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[cmd] = output
+}
+
+// withCommandCache returns an interpolation context with command caching
+// enabled when requested by the declaration. The original context is
+// returned otherwise.
+func withCommandCache(ctx *ExecutionContext, enabled bool) *ExecutionContext {
+	if ctx == nil || !enabled || ctx.CommandCache == nil {
+		return ctx
+	}
+	return &ExecutionContext{
+		Variables:     ctx.Variables,
+		Env:           ctx.Env,
+		Dir:           ctx.Dir,
+		EnvIsolated:   ctx.EnvIsolated,
+		CommandCache:  ctx.CommandCache,
+		cacheCommands: true,
+	}
+}
@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultChangeStateFile is where last successful run times are stored.
+const DefaultChangeStateFile = DefaultStateDir + "/state"
+
+// DefaultBaseRefs are the git refs tried in order when no base ref is given.
+var DefaultBaseRefs = []string{"origin/main", "HEAD~1"}
+
+// ChangeState tracks the last successful run of jobs and steps, so
+// run_if_changed globs can be compared against file modification times.
+// With a base ref set, the globs are compared against the files changed
+// in git since the base ref instead.
+type ChangeState struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]time.Time
+
+	baseRef     string
+	changedOnce sync.Once
+	changed     []string
+	changedErr  error
+}
+
+// LoadChangeState reads the change state from path.
+// A missing file results in an empty state.
+func LoadChangeState(path string) (*ChangeState, error) {
+	state := &ChangeState{
+		path:    path,
+		entries: make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &state.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if state.entries == nil {
+		state.entries = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// SetBaseRef sets the git ref that changes are compared against.
+func (s *ChangeState) SetBaseRef(ref string) {
+	s.baseRef = ref
+}
+
+// BaseRef returns the git ref that changes are compared against, if any.
+func (s *ChangeState) BaseRef() string {
+	return s.baseRef
+}
+
+// Changed returns true if any file matching globs was modified after the
+// last successful run recorded for key, or if there is no recorded run.
+// With a base ref, it returns true if any file matching globs changed
+// in git since the base ref.
+func (s *ChangeState) Changed(key string, globs []string) (bool, error) {
+	if s.baseRef != "" {
+		return s.changedSinceBaseRef(globs)
+	}
+
+	s.mu.Lock()
+	last, ok := s.entries[key]
+	s.mu.Unlock()
+	if !ok {
+		return true, nil
+	}
+
+	for _, glob := range globs {
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return false, fmt.Errorf("invalid run_if_changed pattern %q: %w", glob, err)
+		}
+		for _, match := range matches {
+			newer, err := modifiedAfter(match, last)
+			if err != nil {
+				return false, err
+			}
+			if newer {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// changedSinceBaseRef returns true if a file changed since the base ref
+// matches one of globs, or is below a directory matching one of globs.
+func (s *ChangeState) changedSinceBaseRef(globs []string) (bool, error) {
+	s.changedOnce.Do(func() {
+		s.changed, s.changedErr = gitChangedFiles(s.baseRef)
+	})
+	if s.changedErr != nil {
+		return false, s.changedErr
+	}
+
+	for _, glob := range globs {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return false, fmt.Errorf("invalid run_if_changed pattern %q: %w", glob, err)
+		}
+		for _, file := range s.changed {
+			for path := file; path != "." && path != string(filepath.Separator); path = filepath.Dir(path) {
+				if ok, _ := filepath.Match(glob, path); ok {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// gitChangedFiles lists the files changed between ref and HEAD, in the
+// working tree, and untracked files, relative to the current directory.
+func gitChangedFiles(ref string) ([]string, error) {
+	var files []string
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", ref + "...HEAD"},
+		{"diff", "--name-only", "--relative", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changes since %s: git %s: %w", ref, strings.Join(args, " "), err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				files = append(files, filepath.FromSlash(line))
+			}
+		}
+	}
+	return files, nil
+}
+
+// ResolveBaseRef returns the git ref that changes are compared against:
+// ref if given, otherwise the first of DefaultBaseRefs that exists. It
+// returns an error if ref doesn't exist, and an empty ref if none of the
+// defaults exist, e.g. outside a git repository.
+func ResolveBaseRef(ref string) (string, error) {
+	if ref != "" {
+		if !gitRefExists(ref) {
+			return "", fmt.Errorf("base ref %q not found", ref)
+		}
+		return ref, nil
+	}
+	for _, ref := range DefaultBaseRefs {
+		if gitRefExists(ref) {
+			return ref, nil
+		}
+	}
+	return "", nil
+}
+
+// gitRefExists returns true if ref resolves to a commit.
+func gitRefExists(ref string) bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}").Run() == nil
+}
+
+// MarkSuccess records a successful run for key that started at t,
+// and saves the state file.
+func (s *ChangeState) MarkSuccess(key string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = t
+	return s.save()
+}
+
+// save writes the state atomically by renaming a temp file into place.
+func (s *ChangeState) save() error {
+	data, err := yaml.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// modifiedAfter returns true if path, or any file below it, has been modified after t.
+func modifiedAfter(path string, t time.Time) (bool, error) {
+	newer := false
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(t) {
+			newer = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return newer, err
+}
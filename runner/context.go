@@ -3,6 +3,8 @@ package runner
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/titpetric/atkins/eventlog"
 	"github.com/titpetric/atkins/model"
@@ -18,7 +20,17 @@ type IterationContext struct {
 type ExecutionContext struct {
 	Context context.Context
 
-	Env     map[string]string
+	Env map[string]string
+	Dir string // Working directory for commands, empty for the current directory
+	// EnvIsolated commands only see Env, without inheriting the OS environment.
+	EnvIsolated bool
+	// Profile selects <file>.<profile> env includes, layered after each base file.
+	Profile string
+	// StrictEnv fails on env include files readable by group or others, instead of a warning.
+	StrictEnv bool
+	// SubstitutionTimeout limits each $(...) substitution, DefaultSubstitutionTimeout if zero.
+	SubstitutionTimeout time.Duration
+
 	Results map[string]any
 	Verbose bool
 
@@ -44,34 +56,126 @@ type ExecutionContext struct {
 	StepSequence int
 	stepSeqMu    sync.Mutex
 
+	// stepIDSuffix is appended to step IDs inside for loop iterations.
+	stepIDSuffix string
+
+	// taskChain holds the job and the tasks it invoked, leading to this context.
+	taskChain []string
+
 	// JobCompleted tracks which jobs have finished execution (for dependency resolution)
 	JobCompleted map[string]bool
 	jobCompMu    sync.Mutex
+
+	// failed is set once a step in the current job fails, shared between copies.
+	failed *atomic.Bool
+
+	// retryBudget holds the step retries left for the current job, shared between copies.
+	retryBudget *atomic.Int64
+
+	// executed counts the steps of the current job that ran, shared between copies.
+	executed *atomic.Int64
+
+	// stepResults holds the results of steps with an id, shared between copies.
+	stepResults *stepResults
+
+	// detached holds the evaluated detach of steps, shared between copies.
+	detached *stepDetach
+
+	// ChangeState is set when only changed jobs and steps should run.
+	ChangeState *ChangeState
+
+	// CommandCache holds memoized $(...) output, shared for the whole run.
+	CommandCache *CommandCache
+	// Redactor scrubs patterns from captured output, nil to keep it as is.
+	Redactor *Redactor
+	// cacheCommands enables CommandCache lookups for the current declaration.
+	cacheCommands bool
 }
 
-// Copy copies everything except Context. Variables are shallow-copied.
+// Copy copies everything except Context. Variables are shallow-copied,
+// with their resolved values; declarations aren't evaluated again.
 // JobCompleted is shared (not copied) to maintain consistent dependency tracking.
 func (e *ExecutionContext) Copy() *ExecutionContext {
 	return &ExecutionContext{
-		Variables:    copyVariables(e.Variables),
-		Env:          copyEnv(e.Env),
-		Results:      e.Results,
-		Verbose:      e.Verbose,
-		Pipeline:     e.Pipeline,
-		Job:          e.Job,
-		Step:         e.Step,
-		Depth:        e.Depth + 1,
-		StepsCount:   e.StepsCount,
-		StepsPassed:  e.StepsPassed,
-		CurrentJob:   e.CurrentJob,
-		CurrentStep:  e.CurrentStep,
-		Display:      e.Display,
-		Builder:      e.Builder,
-		JobNodes:     e.JobNodes,
-		EventLogger:  e.EventLogger,
-		StepSequence: e.StepSequence,
-		JobCompleted: e.JobCompleted,
+		Variables:           copyVariables(e.Variables),
+		Env:                 copyEnv(e.Env),
+		Dir:                 e.Dir,
+		EnvIsolated:         e.EnvIsolated,
+		Profile:             e.Profile,
+		StrictEnv:           e.StrictEnv,
+		Results:             e.Results,
+		Verbose:             e.Verbose,
+		Pipeline:            e.Pipeline,
+		Job:                 e.Job,
+		Step:                e.Step,
+		Depth:               e.Depth + 1,
+		StepsCount:          e.StepsCount,
+		StepsPassed:         e.StepsPassed,
+		CurrentJob:          e.CurrentJob,
+		CurrentStep:         e.CurrentStep,
+		Display:             e.Display,
+		Builder:             e.Builder,
+		JobNodes:            e.JobNodes,
+		EventLogger:         e.EventLogger,
+		StepSequence:        e.StepSequence,
+		stepIDSuffix:        e.stepIDSuffix,
+		taskChain:           e.taskChain,
+		JobCompleted:        e.JobCompleted,
+		CommandCache:        e.CommandCache,
+		Redactor:            e.Redactor,
+		ChangeState:         e.ChangeState,
+		failed:              e.failed,
+		retryBudget:         e.retryBudget,
+		executed:            e.executed,
+		stepResults:         e.stepResults,
+		detached:            e.detached,
+		SubstitutionTimeout: e.SubstitutionTimeout,
+	}
+}
+
+// markFailed records a step failure for the current job.
+func (e *ExecutionContext) markFailed() {
+	if e.failed == nil {
+		e.failed = new(atomic.Bool)
 	}
+	e.failed.Store(true)
+}
+
+// markExecuted records that a step of the current job ran.
+func (e *ExecutionContext) markExecuted() {
+	if e.executed != nil {
+		e.executed.Add(1)
+	}
+}
+
+// Executed returns the number of steps of the current job that ran.
+func (e *ExecutionContext) Executed() int {
+	if e.executed == nil {
+		return 0
+	}
+	return int(e.executed.Load())
+}
+
+// takeRetry consumes one retry from the job retry budget.
+// Returns false if the budget is exhausted.
+func (e *ExecutionContext) takeRetry() bool {
+	if e.retryBudget == nil {
+		return false
+	}
+	for {
+		left := e.retryBudget.Load()
+		if left <= 0 {
+			return false
+		}
+		if e.retryBudget.CompareAndSwap(left, left-1) {
+			return true
+		}
+	}
+}
+
+// Failed returns true if a step in the current job has failed.
+func (e *ExecutionContext) Failed() bool {
+	return e.failed != nil && e.failed.Load()
 }
 
 // MarkJobCompleted marks a job as completed.
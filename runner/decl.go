@@ -20,6 +20,10 @@ func ProcessDecl(decl *model.Decl, ctx *ExecutionContext) (map[string]any, error
 	// First, load included files
 	if decl != nil && decl.Include != nil {
 		for _, filename := range decl.Include.Files {
+			filename, err := interpolateIncludePath(filename, ctx)
+			if err != nil {
+				return nil, err
+			}
 			if err := loadYaml(filename, &result); err != nil {
 				return nil, fmt.Errorf("failed to load vars file %q: %w", filename, err)
 			}
@@ -28,7 +32,7 @@ func ProcessDecl(decl *model.Decl, ctx *ExecutionContext) (map[string]any, error
 
 	// Then, process and interpolate vars (they override included values)
 	if decl != nil && decl.Vars != nil {
-		interpolated, err := interpolateVariables(ctx, decl.Vars)
+		interpolated, err := interpolateVariables(withCommandCache(ctx, decl.Cache), decl.Vars)
 		if err != nil {
 			return nil, fmt.Errorf("failed to interpolate vars: %w", err)
 		}
@@ -40,6 +44,16 @@ func ProcessDecl(decl *model.Decl, ctx *ExecutionContext) (map[string]any, error
 	return result, nil
 }
 
+// interpolateIncludePath interpolates an include path with the variables
+// and env of ctx, which hold the enclosing scopes of the include.
+func interpolateIncludePath(filePath string, ctx *ExecutionContext) (string, error) {
+	interpolated, err := InterpolateString(filePath, ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to interpolate include %q: %w", filePath, err)
+	}
+	return interpolated, nil
+}
+
 func loadYaml(filename string, dest any) error {
 	data, err := os.ReadFile(filename)
 	if err != nil {
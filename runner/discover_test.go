@@ -106,3 +106,46 @@ func TestDiscoverConfig_DeepNesting(t *testing.T) {
 	assert.Equal(t, configPath, foundPath)
 	assert.Equal(t, tmpDir, foundDir)
 }
+
+func TestDiscoverConfig_NameOrder(t *testing.T) {
+	// Each name is preferred over the names after it
+	for i, name := range runner.ConfigNames {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, other := range runner.ConfigNames[i:] {
+				err := os.WriteFile(filepath.Join(tmpDir, other), []byte("name: "+other), 0o644)
+				require.NoError(t, err)
+			}
+
+			foundPath, foundDir, err := runner.DiscoverConfig(tmpDir)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(tmpDir, name), foundPath)
+			assert.Equal(t, tmpDir, foundDir)
+		})
+	}
+}
+
+func TestDiscoverConfig_NearestMatch(t *testing.T) {
+	// Create temp directory structure
+	tmpDir := t.TempDir()
+	subDir := filepath.Join(tmpDir, "sub")
+	deepDir := filepath.Join(subDir, "deep")
+	err := os.MkdirAll(deepDir, 0o755)
+	require.NoError(t, err)
+
+	// A less preferred name closer to the start wins over the parent
+	err = os.WriteFile(filepath.Join(tmpDir, ".atkins.yml"), []byte("name: root"), 0o644)
+	require.NoError(t, err)
+	configPath := filepath.Join(subDir, "atkins.yaml")
+	err = os.WriteFile(configPath, []byte("name: sub"), 0o644)
+	require.NoError(t, err)
+
+	// Directories with a config name are skipped
+	err = os.Mkdir(filepath.Join(deepDir, ".atkins.yml"), 0o755)
+	require.NoError(t, err)
+
+	foundPath, foundDir, err := runner.DiscoverConfig(deepDir)
+	require.NoError(t, err)
+	assert.Equal(t, configPath, foundPath)
+	assert.Equal(t, subDir, foundDir)
+}
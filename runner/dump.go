@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// WriteResolved writes pipelines as the loader produced them to path, one
+// YAML document each. Job names, depends_on and include paths are resolved,
+// so the file can be loaded again from any directory.
+func WriteResolved(path string, pipelines []*model.Pipeline) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, pipeline := range pipelines {
+		if err := enc.Encode(pipeline); err != nil {
+			return fmt.Errorf("failed to encode pipeline %q: %w", pipeline.Name, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
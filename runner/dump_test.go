@@ -0,0 +1,54 @@
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestWriteResolved(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vars.yml"), []byte("region: eu\n"), 0o644))
+
+	pipelineFile := filepath.Join(dir, "atkins.yml")
+	require.NoError(t, os.WriteFile(pipelineFile, []byte(`
+name: resolved
+include: vars.yml
+jobs:
+  build-linux:
+    steps:
+      - printf build
+  test:
+    vars:
+      os: linux
+    depends_on: build-${{ os }}
+    steps:
+      - printf test
+`), 0o644))
+
+	pipelines, err := runner.LoadPipeline(pipelineFile)
+	require.NoError(t, err)
+
+	out := filepath.Join(t.TempDir(), "resolved.yml")
+	require.NoError(t, runner.WriteResolved(out, pipelines))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "decl:")
+	assert.NotContains(t, string(data), "${{ os }}")
+
+	// The resolved file loads the same from another directory
+	resolved, err := runner.LoadPipeline(out)
+	require.NoError(t, err)
+	require.Len(t, resolved, 1)
+
+	assert.Equal(t, "resolved", resolved[0].Name)
+	assert.Equal(t, []string{"build-linux"}, []string(resolved[0].Jobs["test"].DependsOn))
+	assert.Equal(t, "linux", resolved[0].Jobs["test"].Vars["os"])
+	assert.Equal(t, []string{filepath.Join(dir, "vars.yml")}, resolved[0].Include.Files)
+}
@@ -2,13 +2,21 @@ package runner
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
 
+	"github.com/titpetric/atkins/colors"
 	"github.com/titpetric/atkins/model"
 )
 
+// StepEnvVar names the file that commands of a step write KEY=value
+// lines to, loaded into the env of the later commands of the same step.
+const StepEnvVar = "ATKINS_STEP_ENV"
+
 // mergeEnv merges environment variables from EnvDecl into the execution context.
 // Handles both workflow-level, job-level, and step-level env declarations.
 func mergeEnv(decl *model.EnvDecl, ctx *ExecutionContext) error {
@@ -32,35 +40,127 @@ func mergeEnv(decl *model.EnvDecl, ctx *ExecutionContext) error {
 // processEnv processes an EnvDecl and returns a map of environment variables.
 // It handles:
 // - Manual vars with interpolation ($(...), ${{ ... }})
-// - Include files (.env format)
-// Vars take precedence over included files.
+// - Include files (.env format) with interpolated paths, each followed by <file>.<profile> if present
+// - Import files (.env format), with interpolated paths
+// Vars take precedence over included and imported files.
 func processEnv(decl *model.EnvDecl, ctx *ExecutionContext) (map[string]string, error) {
 	result := make(map[string]string)
 
 	// First, load included files
 	if decl != nil && decl.Include != nil {
 		for _, filePath := range decl.Include.Files {
+			filePath, err := interpolateIncludePath(filePath, ctx)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkEnvFileMode(filePath, ctx.StrictEnv); err != nil {
+				return nil, err
+			}
 			if err := loadEnvFile(filePath, result); err != nil {
 				return nil, fmt.Errorf("failed to load env file %q: %w", filePath, err)
 			}
+			if err := loadProfileEnvFile(filePath, ctx, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Then, load imported files, e.g. written by a dependency
+	if decl != nil && decl.Import != nil {
+		for _, filePath := range decl.Import.Files {
+			interpolated, err := InterpolateString(filePath, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to interpolate env import %q: %w", filePath, err)
+			}
+			if err := loadEnvFile(interpolated, result); err != nil {
+				return nil, fmt.Errorf("failed to import env file %q: %w", interpolated, err)
+			}
 		}
 	}
 
 	// Then, process and interpolate vars (they override included values)
 	if decl != nil && decl.Vars != nil {
-		interpolated, err := interpolateVariables(ctx, decl.Vars)
+		interpolated, err := interpolateVariables(withCommandCache(ctx, decl.Cache), decl.Vars)
 		if err != nil {
 			return nil, fmt.Errorf("failed to interpolate env vars: %w", err)
 		}
 		for k, v := range interpolated {
-			// Convert to string
-			result[k] = fmt.Sprintf("%v", v)
+			value, err := envString(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert env var %q: %w", k, err)
+			}
+			result[k] = value
 		}
 	}
 
 	return result, nil
 }
 
+// envString converts a value to an environment variable string.
+// Lists and maps are serialized as JSON so child programs can parse them.
+func envString(v any) (string, error) {
+	switch v.(type) {
+	case []any, map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return fmt.Sprintf("%v", v), nil
+}
+
+// loadProfileEnvFile loads the <file>.<profile> sibling of an env include
+// on top of env. A missing profile file is not an error.
+func loadProfileEnvFile(filePath string, ctx *ExecutionContext, env map[string]string) error {
+	if ctx.Profile == "" {
+		return nil
+	}
+
+	profilePath := filePath + "." + ctx.Profile
+	if _, err := os.Stat(os.ExpandEnv(profilePath)); os.IsNotExist(err) {
+		return nil
+	}
+	if err := checkEnvFileMode(profilePath, ctx.StrictEnv); err != nil {
+		return err
+	}
+	if err := loadEnvFile(profilePath, env); err != nil {
+		return fmt.Errorf("failed to load env file %q: %w", profilePath, err)
+	}
+	return nil
+}
+
+// warnedEnvFiles holds the env files already reported by checkEnvFileMode.
+var warnedEnvFiles sync.Map
+
+// checkEnvFileMode reports env files readable by group or others, as they
+// may contain secrets. With strict set an error is returned, otherwise a
+// warning is printed once per file. Missing files are left to the loader.
+func checkEnvFileMode(filePath string, strict bool) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	expandedPath := os.ExpandEnv(filePath)
+	info, err := os.Stat(expandedPath)
+	if err != nil {
+		return nil
+	}
+
+	mode := info.Mode().Perm()
+	if mode&0o044 == 0 {
+		return nil
+	}
+
+	if strict {
+		return fmt.Errorf("env file %q is readable by group or others (mode %04o), restrict it with chmod 600", expandedPath, mode)
+	}
+	if _, warned := warnedEnvFiles.LoadOrStore(expandedPath, true); !warned {
+		fmt.Fprintf(os.Stderr, "%s env file %q is readable by group or others (mode %04o), consider chmod 600\n", colors.BrightYellow("!"), expandedPath, mode)
+	}
+	return nil
+}
+
 // loadEnvFile reads a .env file and populates the env map.
 // Format: KEY=VALUE (one per line, # for comments)
 func loadEnvFile(filePath string, env map[string]string) error {
@@ -3,6 +3,7 @@ package runner
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,9 +18,11 @@ func TestProcessEnv_VarsOnly(t *testing.T) {
 	}
 
 	envDecl := &model.EnvDecl{
-		Vars: map[string]any{
-			"KEY1": "value1",
-			"KEY2": "value2",
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"KEY1": "value1",
+				"KEY2": "value2",
+			},
 		},
 	}
 
@@ -38,8 +41,10 @@ func TestProcessEnv_WithInterpolation(t *testing.T) {
 	}
 
 	envDecl := &model.EnvDecl{
-		Vars: map[string]any{
-			"FULL_PATH": "${{ BASE_PATH }}/config",
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"FULL_PATH": "${{ BASE_PATH }}/config",
+			},
 		},
 	}
 
@@ -55,8 +60,10 @@ func TestProcessEnv_WithCommandExecution(t *testing.T) {
 	}
 
 	envDecl := &model.EnvDecl{
-		Vars: map[string]any{
-			"HOSTNAME": "$(hostname)",
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"HOSTNAME": "$(hostname)",
+			},
 		},
 	}
 
@@ -106,8 +113,10 @@ func TestMergeEnv(t *testing.T) {
 	}
 
 	envDecl := &model.EnvDecl{
-		Vars: map[string]any{
-			"NEW_KEY": "new_value",
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"NEW_KEY": "new_value",
+			},
 		},
 	}
 
@@ -116,6 +125,30 @@ func TestMergeEnv(t *testing.T) {
 	assert.Equal(t, "new_value", ctx.Env["NEW_KEY"], "new env var should be merged")
 }
 
+func TestMergeEnv_SelfReference(t *testing.T) {
+	ctx := &ExecutionContext{
+		Env:       map[string]string{"PATH": "/usr/bin:/bin"},
+		Variables: make(map[string]any),
+	}
+
+	envDecl := &model.EnvDecl{
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"PATH":   "${{ PATH }}:/custom",
+				"PREFIX": "/opt:${{ PREFIX ?? '/usr' }}",
+			},
+		},
+	}
+
+	assert.NoError(t, mergeEnv(envDecl, ctx))
+	assert.Equal(t, "/usr/bin:/bin:/custom", ctx.Env["PATH"])
+	assert.Equal(t, "/opt:/usr", ctx.Env["PREFIX"])
+
+	// A nested scope extends the value of the enclosing one
+	assert.NoError(t, mergeEnv(&model.EnvDecl{Decl: model.Decl{Vars: map[string]any{"PATH": "/job:${{ PATH }}"}}}, ctx))
+	assert.Equal(t, "/job:/usr/bin:/bin:/custom", ctx.Env["PATH"])
+}
+
 func TestEnvDeclPrecedence(t *testing.T) {
 	// Create temp env file with a value
 	tmpDir := t.TempDir()
@@ -128,10 +161,12 @@ func TestEnvDeclPrecedence(t *testing.T) {
 	}
 
 	envDecl := &model.EnvDecl{
-		Include: &model.IncludeDecl{Files: []string{envFile}},
-		Vars: map[string]any{
-			"KEY":   "from_vars", // Should override file
-			"OTHER": "value",
+		Decl: model.Decl{
+			Include: &model.IncludeDecl{Files: []string{envFile}},
+			Vars: map[string]any{
+				"KEY":   "from_vars", // Should override file
+				"OTHER": "value",
+			},
 		},
 	}
 
@@ -173,8 +208,10 @@ func TestProcessEnv_NoInterpolationWithoutContext(t *testing.T) {
 	}
 
 	envDecl := &model.EnvDecl{
-		Vars: map[string]any{
-			"PLAIN": "no_interpolation",
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"PLAIN": "no_interpolation",
+			},
 		},
 	}
 
@@ -190,9 +227,11 @@ func TestProcessEnv_IntegerValue(t *testing.T) {
 	}
 
 	envDecl := &model.EnvDecl{
-		Vars: map[string]any{
-			"PORT":  8080,
-			"DEBUG": true,
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"PORT":  8080,
+				"DEBUG": true,
+			},
 		},
 	}
 
@@ -202,6 +241,33 @@ func TestProcessEnv_IntegerValue(t *testing.T) {
 	assert.Equal(t, "true", result["DEBUG"])
 }
 
+func TestProcessEnv_ListAndMapAsJSON(t *testing.T) {
+	ctx := &ExecutionContext{
+		Env:       make(map[string]string),
+		Variables: make(map[string]any),
+	}
+
+	envDecl := &model.EnvDecl{
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"TARGETS": []any{"linux", "darwin"},
+				"CONFIG":  map[string]any{"port": 8080, "tags": []any{"a"}},
+			},
+		},
+	}
+
+	result, err := processEnv(envDecl, ctx)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["linux","darwin"]`, result["TARGETS"])
+	assert.JSONEq(t, `{"port":8080,"tags":["a"]}`, result["CONFIG"])
+
+	// The child process sees valid JSON
+	exec := NewExecWithEnv(result)
+	out, err := exec.ExecuteCommand(`printf '%s' "$CONFIG"`)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"port":8080,"tags":["a"]}`, out)
+}
+
 func TestLoadEnvFile_SingleQuote(t *testing.T) {
 	tmpDir := t.TempDir()
 	envFile := filepath.Join(tmpDir, "test.env")
@@ -211,3 +277,186 @@ func TestLoadEnvFile_SingleQuote(t *testing.T) {
 	assert.NoError(t, loadEnvFile(envFile, env))
 	assert.Equal(t, "single quoted value", env["KEY"])
 }
+
+func TestProcessEnv_CachedCommandRunsOnce(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	cmd := "$(echo run >> " + counter + "; echo cached)"
+
+	ctx := &ExecutionContext{
+		Env:          make(map[string]string),
+		Variables:    make(map[string]any),
+		CommandCache: NewCommandCache(),
+	}
+
+	envDecl := &model.EnvDecl{
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"FIRST":  cmd,
+				"SECOND": cmd,
+			},
+			Cache: true,
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		result, err := processEnv(envDecl, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "cached", result["FIRST"])
+		assert.Equal(t, "cached", result["SECOND"])
+	}
+
+	data, err := os.ReadFile(counter)
+	assert.NoError(t, err)
+	assert.Equal(t, "run\n", string(data))
+}
+
+func TestProcessEnv_UncachedCommandRunsEachTime(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+
+	ctx := &ExecutionContext{
+		Env:          make(map[string]string),
+		Variables:    make(map[string]any),
+		CommandCache: NewCommandCache(),
+	}
+
+	envDecl := &model.EnvDecl{
+		Decl: model.Decl{
+			Vars: map[string]any{
+				"VALUE": "$(echo run >> " + counter + "; echo uncached)",
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := processEnv(envDecl, ctx)
+		assert.NoError(t, err)
+	}
+
+	data, err := os.ReadFile(counter)
+	assert.NoError(t, err)
+	assert.Equal(t, "run\nrun\n", string(data))
+}
+
+func TestProcessEnv_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	envFile := filepath.Join(tmpDir, ".env")
+	os.WriteFile(envFile, []byte("KEY=base\nBASE_ONLY=yes\n"), 0o644)
+	os.WriteFile(envFile+".prod", []byte("KEY=prod\n"), 0o644)
+
+	envDecl := &model.EnvDecl{
+		Decl: model.Decl{
+			Include: &model.IncludeDecl{Files: []string{envFile}},
+		},
+	}
+
+	t.Run("profile overrides base", func(t *testing.T) {
+		ctx := &ExecutionContext{
+			Env:       make(map[string]string),
+			Variables: make(map[string]any),
+			Profile:   "prod",
+		}
+
+		result, err := processEnv(envDecl, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "prod", result["KEY"])
+		assert.Equal(t, "yes", result["BASE_ONLY"])
+	})
+
+	t.Run("missing profile file is ignored", func(t *testing.T) {
+		ctx := &ExecutionContext{
+			Env:       make(map[string]string),
+			Variables: make(map[string]any),
+			Profile:   "dev",
+		}
+
+		result, err := processEnv(envDecl, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "base", result["KEY"])
+	})
+
+	t.Run("no profile", func(t *testing.T) {
+		ctx := &ExecutionContext{
+			Env:       make(map[string]string),
+			Variables: make(map[string]any),
+		}
+
+		result, err := processEnv(envDecl, ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "base", result["KEY"])
+	})
+}
+
+func TestProcessEnv_ImportMissingFile(t *testing.T) {
+	ctx := &ExecutionContext{
+		Env:       make(map[string]string),
+		Variables: map[string]any{"dir": t.TempDir()},
+	}
+
+	envDecl := &model.EnvDecl{
+		Import: &model.IncludeDecl{Files: []string{"${{ dir }}/missing.env"}},
+	}
+
+	_, err := processEnv(envDecl, ctx)
+	assert.ErrorContains(t, err, "missing.env")
+}
+
+func TestProcessEnv_FileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not checked on windows")
+	}
+
+	tmpDir := t.TempDir()
+	private := filepath.Join(tmpDir, "private.env")
+	shared := filepath.Join(tmpDir, "shared.env")
+	assert.NoError(t, os.WriteFile(private, []byte("TOKEN=private\n"), 0o600))
+	assert.NoError(t, os.WriteFile(shared, []byte("TOKEN=shared\n"), 0o600))
+	assert.NoError(t, os.Chmod(shared, 0o640))
+
+	process := func(file string, strict bool) (map[string]string, error) {
+		ctx := &ExecutionContext{
+			Env:       make(map[string]string),
+			Variables: make(map[string]any),
+			StrictEnv: strict,
+		}
+		return processEnv(&model.EnvDecl{
+			Decl: model.Decl{
+				Include: &model.IncludeDecl{Files: []string{file}},
+			},
+		}, ctx)
+	}
+
+	t.Run("private file", func(t *testing.T) {
+		result, err := process(private, true)
+		assert.NoError(t, err)
+		assert.Equal(t, "private", result["TOKEN"])
+	})
+
+	t.Run("group readable file warns", func(t *testing.T) {
+		result, err := process(shared, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "shared", result["TOKEN"])
+	})
+
+	t.Run("group readable file fails when strict", func(t *testing.T) {
+		_, err := process(shared, true)
+		assert.ErrorContains(t, err, "readable by group or others (mode 0640)")
+	})
+
+	t.Run("world readable profile fails when strict", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(private+".prod", []byte("TOKEN=prod\n"), 0o600))
+		assert.NoError(t, os.Chmod(private+".prod", 0o604))
+
+		ctx := &ExecutionContext{
+			Env:       make(map[string]string),
+			Variables: make(map[string]any),
+			Profile:   "prod",
+			StrictEnv: true,
+		}
+		_, err := processEnv(&model.EnvDecl{
+			Decl: model.Decl{
+				Include: &model.IncludeDecl{Files: []string{private}},
+			},
+		}, ctx)
+		assert.ErrorContains(t, err, "mode 0604")
+	})
+}
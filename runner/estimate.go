@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/model"
+)
+
+// JobEstimate is the duration of a planned job in a prior run.
+type JobEstimate struct {
+	Name     string
+	Duration float64 // Seconds
+	Known    bool    // False if the job didn't run in the prior run
+}
+
+// Estimate is the expected duration of a plan, based on a prior run.
+type Estimate struct {
+	Jobs    []JobEstimate
+	Total   float64 // Sum of the known job durations in seconds
+	Unknown int     // Number of jobs without a known duration
+}
+
+// EstimatePlan looks up the duration of each planned job in the state
+// of a prior run. Durations are summed, detached jobs running alongside
+// others make the estimate an upper bound.
+func EstimatePlan(plan *Plan, state *eventlog.StateNode) *Estimate {
+	estimate := &Estimate{}
+	for _, job := range plan.Jobs {
+		jobEstimate := JobEstimate{Name: job.Name}
+		if node := findStateNode(state, "jobs."+job.Name); node != nil && node.Duration > 0 {
+			jobEstimate.Duration = node.Duration
+			jobEstimate.Known = true
+			estimate.Total += node.Duration
+		} else {
+			estimate.Unknown++
+		}
+		estimate.Jobs = append(estimate.Jobs, jobEstimate)
+	}
+	return estimate
+}
+
+// findStateNode returns the first node with id, depth first.
+func findStateNode(node *eventlog.StateNode, id string) *eventlog.StateNode {
+	if node == nil {
+		return nil
+	}
+	if node.ID == id {
+		return node
+	}
+	for _, child := range node.Children {
+		if found := findStateNode(child, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// LatestLog returns the most recently modified event log of pipeline
+// matching pattern, a path or a glob like `logs/*.log`. Logs without a
+// pipeline name are assumed to match, unreadable logs are skipped.
+// Returns nil if none match.
+func LatestLog(pattern, pipeline string) (*eventlog.Log, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log pattern %q: %w", pattern, err)
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	files := make([]logFile, 0, len(matches))
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, logFile{path: match, modTime: info.ModTime()})
+	}
+	slices.SortFunc(files, func(a, b logFile) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	for _, file := range files {
+		log, err := eventlog.ReadLog(file.path)
+		if err != nil {
+			continue
+		}
+		if log.Metadata.Pipeline == "" || log.Metadata.Pipeline == pipeline {
+			return log, nil
+		}
+	}
+	return nil, nil
+}
+
+// PrintPlanDuration prints the estimated duration of the plan for job
+// (or the default job), based on the most recent event log of the
+// pipeline matching pattern. Jobs without history are printed as unknown.
+func PrintPlanDuration(w io.Writer, pipeline *model.Pipeline, job, pattern string) error {
+	plan, err := BuildPlan(pipeline, job)
+	if err != nil {
+		return err
+	}
+
+	log, err := LatestLog(pattern, pipeline.Name)
+	if err != nil {
+		return err
+	}
+	var state *eventlog.StateNode
+	if log != nil {
+		state = log.State
+	}
+
+	estimate := EstimatePlan(plan, state)
+
+	width := 0
+	for _, job := range estimate.Jobs {
+		width = max(width, len(job.Name))
+	}
+	for _, job := range estimate.Jobs {
+		duration := "unknown"
+		if job.Known {
+			duration = fmt.Sprintf("%.1fs", job.Duration)
+		}
+		fmt.Fprintf(w, "%-*s  %s\n", width, job.Name, duration)
+	}
+
+	switch {
+	case estimate.Unknown == len(estimate.Jobs):
+		fmt.Fprintln(w, "estimate: unknown")
+	case estimate.Unknown > 0:
+		fmt.Fprintf(w, "estimate: %.1fs, %s without history\n", estimate.Total, plural(estimate.Unknown, "job"))
+	default:
+		fmt.Fprintf(w, "estimate: %.1fs\n", estimate.Total)
+	}
+	return nil
+}
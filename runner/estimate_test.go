@@ -0,0 +1,86 @@
+package runner_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestPrintPlanDuration(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: estimate
+jobs:
+  default:
+    depends_on: [build, test, lint]
+    steps:
+      - echo done
+  build:
+    steps:
+      - go build ./...
+  test:
+    steps:
+      - go test ./...
+  lint:
+    steps:
+      - go vet ./...
+`)
+
+	// A synthetic history, lint didn't run and the older log is ignored
+	dir := t.TempDir()
+	writeLog := func(name, pipeline string, modTime time.Time, jobs map[string]float64) {
+		state := &eventlog.StateNode{Name: pipeline}
+		for job, duration := range jobs {
+			state.Children = append(state.Children, &eventlog.StateNode{Name: job, ID: "jobs." + job, Duration: duration})
+		}
+		data, err := yaml.Marshal(&eventlog.Log{Metadata: eventlog.RunMetadata{Pipeline: pipeline}, State: state})
+		require.NoError(t, err)
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	now := time.Now()
+	writeLog("1.log", "estimate", now.Add(-time.Hour), map[string]float64{"build": 100, "test": 100, "lint": 100, "default": 100})
+	writeLog("2.log", "estimate", now, map[string]float64{"build": 12.5, "test": 30.25, "default": 0.5})
+
+	var out bytes.Buffer
+	require.NoError(t, runner.PrintPlanDuration(&out, pipeline, "", filepath.Join(dir, "*.log")))
+	assert.Equal(t, `build    12.5s
+test     30.2s
+lint     unknown
+default  0.5s
+estimate: 43.2s, 1 job without history
+`, out.String())
+
+	// A newer log of another pipeline is passed over
+	writeLog("3.log", "other", now.Add(time.Hour), map[string]float64{"build": 1})
+	out.Reset()
+	require.NoError(t, runner.PrintPlanDuration(&out, pipeline, "build", filepath.Join(dir, "*.log")))
+	assert.Equal(t, "build  12.5s\nestimate: 12.5s\n", out.String())
+
+	// A newer log that can't be parsed is skipped
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "4.log"), []byte("state: [unclosed"), 0o644))
+	future := now.Add(2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "4.log"), future, future))
+	out.Reset()
+	require.NoError(t, runner.PrintPlanDuration(&out, pipeline, "build", filepath.Join(dir, "*.log")))
+	assert.Equal(t, "build  12.5s\nestimate: 12.5s\n", out.String())
+
+	// Logs of other pipelines only are no history
+	out.Reset()
+	require.NoError(t, runner.PrintPlanDuration(&out, pipeline, "build", filepath.Join(dir, "3.log")))
+	assert.Equal(t, "build  unknown\nestimate: unknown\n", out.String())
+
+	// No log at all
+	out.Reset()
+	require.NoError(t, runner.PrintPlanDuration(&out, pipeline, "build", filepath.Join(dir, "missing.log")))
+	assert.Equal(t, "build  unknown\nestimate: unknown\n", out.String())
+}
@@ -2,10 +2,15 @@ package runner
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/expr-lang/expr"
+
+	"github.com/titpetric/atkins/model"
 )
 
 // EvaluateIf evaluates the If condition using expr-lang.
@@ -17,7 +22,7 @@ func EvaluateIf(ctx *ExecutionContext) (bool, error) {
 		return true, nil // No condition means always execute
 	}
 
-	prog, err := expr.Compile(s.If, expr.AllowUndefinedVariables())
+	prog, err := expr.Compile(s.If, numericOptions(expr.AllowUndefinedVariables())...)
 	if err != nil {
 		return false, fmt.Errorf("failed to compile if expression %q: %w", s.If, err)
 	}
@@ -35,29 +40,90 @@ func EvaluateIf(ctx *ExecutionContext) (bool, error) {
 		env[k] = v
 	}
 
+	// Add job status functions
+	env["always"] = func() bool { return true }
+	env["failure"] = func() bool { return ctx.Failed() }
+	env["success"] = func() bool { return !ctx.Failed() }
+
 	// Run the compiled program
 	result, err := expr.Run(prog, env)
 	if err != nil {
 		return false, fmt.Errorf("failed to evaluate if expression %q: %w", s.If, err)
 	}
 
-	// Coerce the result to boolean
+	return isTruthy(result), nil
+}
+
+// EvaluateDetach returns true if a job or step with detach d runs in the
+// background. Expressions are interpolated with ctx, e.g. "${{ CI == 'true' }}".
+func EvaluateDetach(d model.Detach, ctx *ExecutionContext) (bool, error) {
+	if d.Expr == "" {
+		return d.Enabled, nil
+	}
+	value, err := InterpolateString(d.Expr, ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate detach expression %q: %w", d.Expr, err)
+	}
+	return isTruthy(strings.TrimSpace(value)), nil
+}
+
+// stepDetach holds the evaluated detach of the steps of a job run.
+type stepDetach struct {
+	mu     sync.Mutex
+	values map[*model.Step]bool
+}
+
+func newStepDetach() *stepDetach {
+	return &stepDetach{values: make(map[*model.Step]bool)}
+}
+
+// stepDetached returns the detach of step, evaluated once per job run so
+// scheduling the step and running its commands agree. Steps of the job
+// are evaluated in the job context when scheduled.
+func (e *ExecutionContext) stepDetached(step *model.Step) (bool, error) {
+	if e.detached == nil {
+		return EvaluateDetach(step.Detach, e)
+	}
+
+	e.detached.mu.Lock()
+	defer e.detached.mu.Unlock()
+	if detach, ok := e.detached.values[step]; ok {
+		return detach, nil
+	}
+	detach, err := EvaluateDetach(step.Detach, e)
+	if err != nil {
+		return false, err
+	}
+	e.detached.values[step] = detach
+	return detach, nil
+}
+
+// isTruthy coerces an expression result to boolean.
+func isTruthy(result any) bool {
 	switch v := result.(type) {
 	case bool:
-		return v, nil
+		return v
 	case nil:
-		return false, nil
+		return false
 	case string:
-		return v != "" && v != "false" && v != "0", nil
+		return v != "" && v != "false" && v != "0"
 	case int, int32, int64:
-		return result != 0, nil
+		return result != 0
 	case float32, float64:
-		return result != 0.0, nil
+		return result != 0.0
 	default:
-		return true, nil // Non-zero/non-nil values are truthy
+		return true // Non-zero/non-nil values are truthy
 	}
 }
 
+var statusCheckRe = regexp.MustCompile(`\b(success|failure|always)\(\)`)
+
+// HasStatusCheck returns true if the condition calls success(), failure() or always().
+// Steps without a status check are skipped once a prior step in the job failed.
+func HasStatusCheck(cond string) bool {
+	return statusCheckRe.MatchString(cond)
+}
+
 // ExpandFor expands a for loop into multiple iteration contexts.
 // Supports patterns: "item in items" (items is a variable name),
 // "(index, item) in items", "(key, value) in items",
@@ -161,6 +227,26 @@ func parseForPattern(forSpec string) (string, string, string, string, error) {
 	return "", "", "", "", fmt.Errorf("unrecognized for pattern, expected 'item in items' or '(idx, item) in items'")
 }
 
+// iterationLabel returns name followed by the loop variables of a
+// for loop iteration, e.g. `build (pkg=api)`.
+func iterationLabel(name, forSpec string, vars map[string]any) string {
+	_, loopVar, indexVar, keyVar, err := parseForPattern(forSpec)
+	if err != nil {
+		return name
+	}
+
+	var pairs []string
+	for _, key := range []string{indexVar, keyVar, loopVar} {
+		if key != "" {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", key, vars[key]))
+		}
+	}
+	if len(pairs) == 0 {
+		return name
+	}
+	return name + " (" + strings.Join(pairs, ", ") + ")"
+}
+
 // getForItems retrieves the items list for a for loop
 // itemsSpec can be:
 //   - A variable name: "items"
@@ -203,7 +289,7 @@ func getForItems(ctx *ExecutionContext, itemsSpec string, executeCommand func(st
 		exprStr = strings.TrimSpace(exprStr)
 
 		// Evaluate expression (supports dot notation, operators, etc)
-		val, err := evaluateExpression(exprStr, ctx)
+		val, err := evaluateExpressionWith(exprStr, ctx, forFunctions(ctx))
 		if err == nil && val != nil {
 			return convertToAnySlice(val)
 		}
@@ -211,11 +297,15 @@ func getForItems(ctx *ExecutionContext, itemsSpec string, executeCommand func(st
 
 	// Try evaluating as an expr-lang expression (e.g., array literals like ["a", "b"])
 	// This supports inline arrays and other expr constructs
-	if val, err := evaluateExpression(itemsSpec, ctx); err == nil && val != nil {
+	val, err := evaluateExpressionWith(itemsSpec, ctx, forFunctions(ctx))
+	if err == nil && val != nil {
 		if items, err := convertToAnySlice(val); err == nil {
 			return items, nil
 		}
 	}
+	if err != nil && strings.Contains(itemsSpec, "file(") {
+		return nil, err
+	}
 
 	// Look up in variables
 	if val, ok := ctx.Variables[itemsSpec]; ok {
@@ -225,6 +315,31 @@ func getForItems(ctx *ExecutionContext, itemsSpec string, executeCommand func(st
 	return nil, fmt.Errorf("variable %q not found in context", itemsSpec)
 }
 
+// forFunctions returns the functions available to for loop items:
+// file("path") returns the non-empty lines of a file, with relative
+// paths resolved against the pipeline directory.
+func forFunctions(ctx *ExecutionContext) map[string]any {
+	return map[string]any{
+		"file": func(path string) ([]string, error) {
+			if !filepath.IsAbs(path) && ctx.Pipeline != nil {
+				path = filepath.Join(ctx.Pipeline.Dir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			var lines []string
+			for _, line := range strings.Split(string(data), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			return lines, nil
+		},
+	}
+}
+
 // convertToAnySlice converts various types to []any for iteration.
 // Supports []any, []string, string (split by newlines), and map[string]any.
 func convertToAnySlice(val any) ([]any, error) {
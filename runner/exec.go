@@ -2,11 +2,14 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/creack/pty"
 	"golang.org/x/term"
@@ -33,6 +36,20 @@ func (r ExecError) Len() int {
 // Exec runs shell commands.
 type Exec struct {
 	Env map[string]string // Optional environment variables to pass to commands
+	Dir string            // Optional working directory for commands
+
+	// Isolated commands only see Env, without inheriting the OS environment.
+	Isolated bool
+
+	Context context.Context // Optional context, cancelling it kills the running command
+
+	// Strict runs the script with `set -euo pipefail`, so any failing line fails it.
+	Strict bool
+
+	Stdin     string    // Optional input fed to the command's stdin
+	StdinFrom io.Reader // Optional reader fed to the command's stdin, instead of Stdin
+	Stdout    io.Writer // Optional writer receiving a copy of stdout
+	Stderr    io.Writer // Optional writer receiving a copy of stderr, kept apart from stdout
 }
 
 // NewExec creates a new Exec instance.
@@ -49,6 +66,48 @@ func NewExecWithEnv(env map[string]string) *Exec {
 	}
 }
 
+// strictPrelude is prepended to scripts in strict mode.
+const strictPrelude = "set -euo pipefail\n"
+
+// command creates the bash command bound to the Exec context and working directory.
+func (e *Exec) command(cmdStr string) *exec.Cmd {
+	if e.Strict {
+		cmdStr = strictPrelude + cmdStr
+	}
+
+	var cmd *exec.Cmd
+	if e.Context != nil {
+		cmd = exec.CommandContext(e.Context, "bash", "-c", cmdStr)
+		// Don't wait forever on output pipes held open by orphaned children
+		cmd.WaitDelay = time.Second
+	} else {
+		cmd = exec.Command("bash", "-c", cmdStr)
+	}
+	cmd.Dir = e.Dir
+	cmd.Env = e.environ()
+	if e.StdinFrom != nil {
+		cmd.Stdin = e.StdinFrom
+	} else if e.Stdin != "" {
+		cmd.Stdin = strings.NewReader(e.Stdin)
+	}
+	return cmd
+}
+
+// environ builds the command environment: the OS environment unless
+// isolated, overlaid with the custom env.
+func (e *Exec) environ() []string {
+	var cmdEnv []string
+	if !e.Isolated {
+		cmdEnv = os.Environ()
+	}
+	for k, v := range e.Env {
+		// Remove existing key if present and add new one
+		cmdEnv = removeEnvKey(cmdEnv, k)
+		cmdEnv = append(cmdEnv, k+"="+v)
+	}
+	return cmdEnv
+}
+
 // ExecuteCommand will run the command quietly.
 func (e *Exec) ExecuteCommand(cmdStr string) (string, error) {
 	return e.ExecuteCommandWithQuiet(cmdStr, false)
@@ -66,20 +125,11 @@ func (e *Exec) ExecuteCommandWithQuietAndCapture(cmdStr string, verbose bool) (s
 		return "", nil
 	}
 
-	cmd := exec.Command("bash", "-c", cmdStr)
-
-	// Build environment: start with OS environment, then overlay custom env
-	cmdEnv := os.Environ()
-	for k, v := range e.Env {
-		// Remove existing key if present and add new one
-		cmdEnv = removeEnvKey(cmdEnv, k)
-		cmdEnv = append(cmdEnv, k+"="+v)
-	}
-	cmd.Env = cmdEnv
+	cmd := e.command(cmdStr)
 
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stdout = teeWriter(&stdout, e.Stdout)
+	cmd.Stderr = teeWriter(&stderr, e.Stderr)
 
 	err := cmd.Run()
 	if err != nil {
@@ -137,16 +187,7 @@ func (e *Exec) ExecuteCommandWithWriter(writer io.Writer, cmdStr string, usePTY
 		return "", nil
 	}
 
-	cmd := exec.Command("bash", "-c", cmdStr)
-
-	// Build environment: start with OS environment, then overlay custom env
-	cmdEnv := os.Environ()
-	for k, v := range e.Env {
-		// Remove existing key if present and add new one
-		cmdEnv = removeEnvKey(cmdEnv, k)
-		cmdEnv = append(cmdEnv, k+"="+v)
-	}
-	cmd.Env = cmdEnv
+	cmd := e.command(cmdStr)
 
 	if usePTY {
 		// Allocate a PTY for the command to enable color output
@@ -170,7 +211,7 @@ func (e *Exec) ExecuteCommandWithWriter(writer io.Writer, cmdStr string, usePTY
 
 		// Copy PTY output to both the buffer and provided writer
 		var stdout bytes.Buffer
-		multiWriter := io.MultiWriter(&stdout, writer)
+		multiWriter := teeWriter(io.MultiWriter(&stdout, writer), e.Stdout)
 		_, _ = io.Copy(multiWriter, ptmx)
 
 		// Wait for command to complete
@@ -196,8 +237,15 @@ func (e *Exec) ExecuteCommandWithWriter(writer io.Writer, cmdStr string, usePTY
 	// Non-PTY execution: use pipes for stdout/stderr
 	var stdout bytes.Buffer
 	multiWriter := io.MultiWriter(&stdout, writer)
-	cmd.Stdout = multiWriter
-	cmd.Stderr = multiWriter
+	if e.Stdout == nil && e.Stderr == nil {
+		cmd.Stdout = multiWriter
+		cmd.Stderr = multiWriter
+	} else {
+		// Separate writers are copied from separate goroutines
+		shared := &syncWriter{w: multiWriter}
+		cmd.Stdout = teeWriter(shared, e.Stdout)
+		cmd.Stderr = teeWriter(shared, e.Stderr)
+	}
 
 	err := cmd.Run()
 	if err != nil {
@@ -218,6 +266,27 @@ func (e *Exec) ExecuteCommandWithWriter(writer io.Writer, cmdStr string, usePTY
 	return stdout.String(), nil
 }
 
+// teeWriter returns w, additionally copying writes to extra if set.
+func teeWriter(w, extra io.Writer) io.Writer {
+	if extra == nil {
+		return w
+	}
+	return io.MultiWriter(w, extra)
+}
+
+// syncWriter serializes writes to the underlying writer.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write implements io.Writer.
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
 // removeEnvKey removes a key from environment variable list
 func removeEnvKey(env []string, key string) []string {
 	prefix := key + "="
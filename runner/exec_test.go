@@ -163,6 +163,36 @@ func TestExecuteCommandWithWriter_StdinPassthrough(t *testing.T) {
 	})
 }
 
+func TestExecuteCommand_Stdin(t *testing.T) {
+	exec := runner.NewExec()
+	exec.Stdin = "line one\nline two\n"
+
+	output, err := exec.ExecuteCommand("cat")
+	assert.NoError(t, err)
+	assert.Equal(t, "line one\nline two\n", output)
+
+	var buf bytes.Buffer
+	output, err = exec.ExecuteCommandWithWriter(&buf, "cat", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "line one\nline two\n", output)
+}
+
+func TestExecuteCommand_Strict(t *testing.T) {
+	script := "false\necho reached"
+
+	exec := runner.NewExec()
+	output, err := exec.ExecuteCommand(script)
+	assert.NoError(t, err)
+	assert.Equal(t, "reached\n", output)
+
+	exec.Strict = true
+	_, err = exec.ExecuteCommand(script)
+	assert.Error(t, err)
+
+	_, err = exec.ExecuteCommand("false | true")
+	assert.Error(t, err, "pipefail")
+}
+
 func TestExecuteCommand_MultipleCommands(t *testing.T) {
 	t.Run("sequential commands with environment", func(t *testing.T) {
 		exec := runner.NewExecWithEnv(map[string]string{
@@ -337,3 +367,34 @@ func TestExecuteCommand_CommandTimeout(t *testing.T) {
 		assert.Contains(t, output, "done")
 	})
 }
+
+func TestExec_SeparateStderr(t *testing.T) {
+	t.Run("quiet mode", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		exec := runner.NewExec()
+		exec.Stdout = &stdout
+		exec.Stderr = &stderr
+
+		output, err := exec.ExecuteCommandWithQuiet("echo out; echo err >&2", false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "out\n", output)
+		assert.Equal(t, "out\n", stdout.String())
+		assert.Equal(t, "err\n", stderr.String())
+	})
+
+	t.Run("writer mode keeps combined display output", func(t *testing.T) {
+		var buf, stdout, stderr bytes.Buffer
+		exec := runner.NewExec()
+		exec.Stdout = &stdout
+		exec.Stderr = &stderr
+
+		_, err := exec.ExecuteCommandWithWriter(&buf, "echo out; echo err >&2", false)
+
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "out")
+		assert.Contains(t, buf.String(), "err")
+		assert.Equal(t, "out\n", stdout.String())
+		assert.Equal(t, "err\n", stderr.String())
+	})
+}
@@ -3,10 +3,17 @@ package runner
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -63,12 +70,24 @@ func (w *LineCapturingWriter) String() string {
 // Options provides configuration for the executor.
 type Options struct {
 	DefaultTimeout time.Duration
+	FailOnEmpty    bool // Fail jobs where no step ran
+
+	// Steps selects the steps of StepsJob to run, others are skipped.
+	Steps    StepSelection
+	StepsJob string
+
+	// MaxTaskDepth limits how deep tasks may invoke other tasks.
+	MaxTaskDepth int
 }
 
+// DefaultMaxTaskDepth is the default limit of nested task invocations.
+const DefaultMaxTaskDepth = 50
+
 // DefaultOptions returns the default executor options.
 func DefaultOptions() *Options {
 	return &Options{
 		DefaultTimeout: 300 * time.Second, // 5 minutes default
+		MaxTaskDepth:   DefaultMaxTaskDepth,
 	}
 }
 
@@ -95,6 +114,7 @@ func NewExecutorWithOptions(opts *Options) *Executor {
 }
 
 // executeCommands executes a list of commands, updating child nodes if available.
+// Commands of detached steps run concurrently, others run in order.
 // Returns the last error encountered (continues on errors to collect all failures).
 func (e *Executor) executeCommands(ctx context.Context, stepCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node, commands []string, stepIndex int) error {
 	if len(commands) == 0 {
@@ -107,16 +127,86 @@ func (e *Executor) executeCommands(ctx context.Context, stepCtx *ExecutionContex
 		cmdNodes = stepNode.GetChildren()
 	}
 
-	var lastErr error
-	for i, cmd := range commands {
-		var cmdNode *treeview.Node
+	cmdNode := func(i int) *treeview.Node {
 		if i < len(cmdNodes) {
-			cmdNode = cmdNodes[i]
-		} else if stepNode != nil {
-			cmdNode = stepNode // Fallback to parent if no child nodes
+			return cmdNodes[i]
 		}
-		if err := e.executeStepIteration(ctx, stepCtx, step, cmdNode, cmd, stepIndex+i); err != nil {
-			lastErr = err
+		return stepNode // Fallback to parent if no child nodes
+	}
+
+	run := func(runCtx *ExecutionContext, i int, cmd string) error {
+		err := e.executeStepIteration(ctx, runCtx, step, cmdNode(i), cmd, stepIndex+i)
+
+		// Retry failing commands while the job retry budget lasts
+		for err != nil && (ctx == nil || ctx.Err() == nil) && runCtx.takeRetry() {
+			err = e.executeStepIteration(ctx, runCtx, step, cmdNode(i), cmd, stepIndex+i)
+		}
+		return err
+	}
+
+	detach, err := stepCtx.stepDetached(step)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	if detach && len(commands) > 1 {
+		// All commands run, there are no later commands to skip on failure
+		var errMu sync.Mutex
+		eg := new(errgroup.Group)
+		eg.SetLimit(runtime.NumCPU())
+		for i, cmd := range commands {
+			// Each command writes its label and output to its own node
+			cmdCtx := stepCtx.Copy()
+			cmdCtx.Context = stepCtx.Context
+			cmdCtx.Depth = stepCtx.Depth
+			if node := cmdNode(i); node != nil {
+				cmdCtx.CurrentStep = node
+			}
+			eg.Go(func() error {
+				if err := run(cmdCtx, i, cmd); err != nil {
+					errMu.Lock()
+					lastErr = err
+					errMu.Unlock()
+				}
+				return nil
+			})
+		}
+		_ = eg.Wait()
+	} else {
+		// Commands export env to the later commands of the step
+		// by writing KEY=value lines to $ATKINS_STEP_ENV.
+		var stepEnv string
+		if len(commands) > 1 {
+			f, err := os.CreateTemp("", "atkins-step-env-*")
+			if err != nil {
+				return fmt.Errorf("failed to create step env file: %w", err)
+			}
+			f.Close()
+			defer os.Remove(f.Name())
+			stepEnv = f.Name()
+			stepCtx.Env[StepEnvVar] = stepEnv
+		}
+
+		for i, cmd := range commands {
+			err := run(stepCtx, i, cmd)
+			if stepEnv != "" {
+				if loadErr := loadEnvFile(stepEnv, stepCtx.Env); loadErr != nil && err == nil {
+					err = fmt.Errorf("failed to load %s: %w", StepEnvVar, loadErr)
+				}
+			}
+			if err != nil {
+				lastErr = err
+
+				// Stop at the first failure unless the step continues on errors
+				if !step.Continue {
+					for j := i + 1; j < len(cmdNodes) && j < len(commands); j++ {
+						cmdNodes[j].SetStatus(treeview.StatusSkipped)
+						cmdNodes[j].SetSkipReason("previous command failed")
+					}
+					break
+				}
+			}
 		}
 	}
 
@@ -165,21 +255,59 @@ func (e *Executor) ExecuteJob(parentCtx context.Context, execCtx *ExecutionConte
 
 	// Store context in execution context for use in steps
 	execCtx.Context = ctx
+	execCtx.failed = new(atomic.Bool)
+	execCtx.retryBudget = new(atomic.Int64)
+	execCtx.retryBudget.Store(int64(job.RetryBudget))
+	execCtx.executed = new(atomic.Int64)
+	execCtx.stepResults = newStepResults()
+	execCtx.detached = newStepDetach()
+
+	// Run in an isolated working directory if requested
+	if job.Workspace != "" {
+		dir, cleanup, err := createWorkspace(job.Workspace, execCtx.Dir, job.Name)
+		if err != nil {
+			return fmt.Errorf("job '%s': %w", job.Name, err)
+		}
+		prevDir := execCtx.Dir
+		defer func() {
+			cleanup()
+			execCtx.Dir = prevDir
+		}()
+		execCtx.Dir = dir
+	}
 
 	// Merge job variables into context with interpolation
 	if err := MergeVariables(job.Decl, execCtx); err != nil {
 		return err
 	}
 
-	// Execute steps
+	// Execute steps, only the selected ones if --steps is used
+	var selection StepSelection
+	if job.Name == e.opts.StepsJob {
+		selection = e.opts.Steps
+	}
 	steps := job.Children()
-	return e.executeSteps(ctx, execCtx, steps)
+	if err := e.executeSteps(ctx, execCtx, steps, selection); err != nil {
+		return err
+	}
+
+	if e.opts.FailOnEmpty && execCtx.Executed() == 0 {
+		return fmt.Errorf("job '%s': no steps were executed", job.Name)
+	}
+	return nil
 }
 
-// executeSteps runs a sequence of steps (deferred steps are already at the end of the list)
-func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext, steps []*model.Step) error {
+// executeSteps runs a sequence of steps (deferred steps are already at the end of the list).
+// Steps not in selection are skipped; a nil selection runs all steps.
+func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext, steps []*model.Step, selection StepSelection) error {
 	eg := new(errgroup.Group)
 
+	// Steps with needs wait for the named earlier steps to finish
+	signals, err := newStepSignals(steps)
+	if err != nil {
+		return err
+	}
+
 	detached := 0
 	deferredSteps := []*model.Step{}
 	deferredIndices := []int{}
@@ -195,6 +323,34 @@ func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext,
 		return nil
 	}
 
+	// After a failure, only steps with a status check in their if
+	// condition (e.g. always(), failure()) keep running.
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+		execCtx.markFailed()
+	}
+	skipAfterFailure := func(step *model.Step, stepNode *treeview.Node) bool {
+		if firstErr == nil || HasStatusCheck(step.If) {
+			return false
+		}
+		if stepNode != nil {
+			stepNode.SetStatus(treeview.StatusSkipped)
+			stepNode.SetSkipReason("previous step failed")
+		}
+		return true
+	}
+	stepNodeAt := func(idx int) *treeview.Node {
+		if execCtx.CurrentJob != nil {
+			if children := execCtx.CurrentJob.GetChildren(); idx < len(children) {
+				return children[idx].Node
+			}
+		}
+		return nil
+	}
+
 	// First pass: execute non-detached steps and collect deferred steps
 	for idx, step := range steps {
 		if step.IsDeferred() {
@@ -204,25 +360,67 @@ func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext,
 			continue
 		}
 
-		if step.Detach {
-			detached++
-			eg.Go(func() error {
-				return e.executeStep(ctx, execCtx, steps[idx], idx)
-			})
+		if !selection.Contains(idx) {
+			if stepNode := stepNodeAt(idx); stepNode != nil {
+				stepNode.SetStatus(treeview.StatusSkipped)
+				stepNode.SetSkipReason("not in --steps")
+			}
+			signals.finish(step, false)
 			continue
 		}
 
-		if err := wait(); err != nil {
-			return err
+		detach, err := execCtx.stepDetached(step)
+		if err != nil {
+			if stepNode := stepNodeAt(idx); stepNode != nil {
+				stepNode.SetStatus(treeview.StatusFailed)
+			}
+			signals.finish(step, true)
+			fail(err)
+			continue
+		}
+
+		if !detach {
+			if err := wait(); err != nil {
+				fail(err)
+			}
+		}
+
+		if skipAfterFailure(step, stepNodeAt(idx)) {
+			signals.finish(step, true)
+			continue
 		}
 
-		if err := e.executeStep(ctx, execCtx, steps[idx], idx); err != nil {
+		// run waits for needed steps, a failed one skips the step
+		run := func() error {
+			if !signals.wait(ctx, step) {
+				if stepNode := stepNodeAt(idx); stepNode != nil {
+					stepNode.SetStatus(treeview.StatusSkipped)
+					stepNode.SetSkipReason("needed step failed")
+				}
+				signals.finish(step, true)
+				return nil
+			}
+			err := e.runIfChanged(execCtx, step, idx, stepNodeAt(idx), func() error {
+				return e.executeStep(ctx, execCtx, steps[idx], idx)
+			})
+			execCtx.stepResults.record(step, err)
+			signals.finish(step, err != nil)
 			return err
 		}
+
+		if detach {
+			detached++
+			eg.Go(run)
+			continue
+		}
+
+		if err := run(); err != nil {
+			fail(err)
+		}
 	}
 
 	if err := wait(); err != nil {
-		return err
+		fail(err)
 	}
 
 	// Second pass: execute deferred steps after all detached steps are done
@@ -247,23 +445,82 @@ func (e *Executor) executeSteps(ctx context.Context, execCtx *ExecutionContext,
 			}
 		}
 
-		if stepNode != nil {
-			// Update status to running and re-render to show the transition
-			stepNode.SetStatus(treeview.StatusRunning)
+		if !selection.Contains(stepIdx) {
+			if stepNode != nil {
+				stepNode.SetStatus(treeview.StatusSkipped)
+				stepNode.SetSkipReason("not in --steps")
+			}
+			continue
+		}
 
-			// Execute step with the actual found node
-			if err := e.executeStepWithNode(ctx, execCtx, step, stepNode); err != nil {
-				return err
+		// Detach of deferred steps is evaluated in the job context too
+		if _, err := execCtx.stepDetached(step); err != nil {
+			if stepNode != nil {
+				stepNode.SetStatus(treeview.StatusFailed)
 			}
-		} else {
-			// Fallback to executeStep if node not found
-			if err := e.executeStep(ctx, execCtx, step, stepIdx); err != nil {
-				return err
+			fail(err)
+			continue
+		}
+
+		if skipAfterFailure(step, stepNode) {
+			continue
+		}
+
+		// Needed steps have finished, a failed one skips the step
+		if !signals.wait(ctx, step) {
+			if stepNode != nil {
+				stepNode.SetStatus(treeview.StatusSkipped)
+				stepNode.SetSkipReason("needed step failed")
+			}
+			continue
+		}
+
+		err := e.runIfChanged(execCtx, step, stepIdx, stepNode, func() error {
+			if stepNode != nil {
+				// Update status to running and re-render to show the transition
+				stepNode.SetStatus(treeview.StatusRunning)
+
+				// Execute step with the actual found node
+				return e.executeStepWithNode(ctx, execCtx, step, stepNode)
 			}
+			// Fallback to executeStep if node not found
+			return e.executeStep(ctx, execCtx, step, stepIdx)
+		})
+		execCtx.stepResults.record(step, err)
+		if err != nil {
+			fail(err)
 		}
 	}
 
-	return nil
+	return firstErr
+}
+
+// runIfChanged calls run unless change detection is enabled and none of the
+// step's run_if_changed files changed since its last successful run.
+func (e *Executor) runIfChanged(execCtx *ExecutionContext, step *model.Step, stepIndex int, stepNode *treeview.Node, run func() error) error {
+	state := execCtx.ChangeState
+	if state == nil || len(step.RunIfChanged) == 0 || execCtx.Job == nil {
+		return run()
+	}
+
+	key := generateStepID(execCtx.Job.Name, stepIndex)
+	changed, err := state.Changed(key, step.RunIfChanged)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		if stepNode != nil {
+			stepNode.SetStatus(treeview.StatusSkipped)
+			stepNode.SetSkipReason("unchanged")
+		}
+		return nil
+	}
+
+	start := time.Now()
+	if err := run(); err != nil {
+		return err
+	}
+	return state.MarkSuccess(key, start)
 }
 
 // executeStepWithNode runs a single step with a provided node
@@ -272,6 +529,7 @@ func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionCo
 	stepCtx := execCtx.Copy()
 	stepCtx.Context = ctx
 	stepCtx.Step = step
+	execCtx.stepResults.apply(stepCtx.Variables)
 
 	env := make(map[string]string)
 	// Copy parent env
@@ -296,6 +554,7 @@ func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionCo
 		// If condition evaluation fails, skip the step
 		if stepNode != nil {
 			stepNode.SetStatus(treeview.StatusSkipped)
+			stepNode.SetSkipReason("if evaluation failed")
 		}
 		return fmt.Errorf("failed to evaluate if condition for step %q: %w", step.Name, err)
 	}
@@ -320,13 +579,18 @@ func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionCo
 		if execCtx.Job != nil {
 			jobName = execCtx.Job.Name
 		}
-		stepID := generateStepID(jobName, seqIndex)
+		stepID := generateStepID(jobName, seqIndex) + execCtx.stepIDSuffix
 		if execCtx.EventLogger != nil {
 			startOffset := execCtx.EventLogger.GetElapsed()
 			execCtx.EventLogger.LogExec(eventlog.ResultSkipped, stepID, stepName, startOffset, 0, nil)
 		}
 		return nil
 	}
+	execCtx.markExecuted()
+
+	if len(step.Parallel) > 0 {
+		return e.executeParallelStep(ctx, stepCtx, step, stepNode)
+	}
 
 	// Handle for loop expansion
 	if step.For != "" {
@@ -342,7 +606,14 @@ func (e *Executor) executeStepWithNode(ctx context.Context, execCtx *ExecutionCo
 	}
 
 	// Execute all commands
-	return e.executeCommands(ctx, stepCtx, step, stepNode, step.Commands(), 0)
+	commands, err := stepCommands(stepCtx, step)
+	if err != nil {
+		if stepNode != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+		}
+		return err
+	}
+	return e.executeCommands(ctx, stepCtx, step, stepNode, commands, 0)
 }
 
 // executeStep runs a single step
@@ -357,6 +628,7 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 	stepCtx := execCtx.Copy()
 	stepCtx.Context = ctx
 	stepCtx.Step = step
+	execCtx.stepResults.apply(stepCtx.Variables)
 	stepCtx.StepSequence = seqIndex // Set the index for this step
 
 	env := make(map[string]string)
@@ -393,6 +665,7 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 		// If condition evaluation fails, skip the step
 		if stepNode != nil {
 			stepNode.SetStatus(treeview.StatusSkipped)
+			stepNode.SetSkipReason("if evaluation failed")
 		}
 		return fmt.Errorf("failed to evaluate if condition for step %q: %w", step.Name, err)
 	}
@@ -415,13 +688,18 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 		if execCtx.Job != nil {
 			jobName = execCtx.Job.Name
 		}
-		stepID := generateStepID(jobName, seqIndex)
+		stepID := generateStepID(jobName, seqIndex) + execCtx.stepIDSuffix
 		if execCtx.EventLogger != nil {
 			startOffset := execCtx.EventLogger.GetElapsed()
 			execCtx.EventLogger.LogExec(eventlog.ResultSkipped, stepID, stepName, startOffset, 0, nil)
 		}
 		return nil
 	}
+	execCtx.markExecuted()
+
+	if len(step.Parallel) > 0 {
+		return e.executeParallelStep(ctx, stepCtx, step, stepNode)
+	}
 
 	// Handle task invocation
 	if step.Task != "" {
@@ -445,7 +723,97 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 	}
 
 	// Execute all commands
-	return e.executeCommands(ctx, stepCtx, step, stepNode, step.Commands(), stepIndex)
+	commands, err := stepCommands(stepCtx, step)
+	if err != nil {
+		if stepNode != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+		}
+		return err
+	}
+	return e.executeCommands(ctx, stepCtx, step, stepNode, commands, stepIndex)
+}
+
+// executeParallelStep runs the steps of a parallel block concurrently,
+// each with its child node, and waits for all of them to finish.
+// Each child logs under its own .parallel.<index> step ID, and the
+// parallel step itself logs under the step ID of the block.
+func (e *Executor) executeParallelStep(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) error {
+	stepName := step.Name
+	jobName := ""
+	if execCtx.Job != nil {
+		jobName = execCtx.Job.Name
+	}
+	stepID := generateStepID(jobName, execCtx.StepSequence) + execCtx.stepIDSuffix
+
+	var startOffset float64
+	if execCtx.EventLogger != nil {
+		startOffset = execCtx.EventLogger.GetElapsed()
+	}
+	startTime := time.Now()
+
+	var childNodes []*treeview.Node
+	if stepNode != nil {
+		if stepName == "" {
+			stepName = stepNode.Name
+		}
+		stepNode.ID = stepID
+		stepNode.SetStartOffset(startOffset)
+		stepNode.SetStatus(treeview.StatusRunning)
+		childNodes = stepNode.GetChildren()
+	}
+
+	eg := new(errgroup.Group)
+	for i, child := range step.Parallel {
+		var childNode *treeview.Node
+		if i < len(childNodes) {
+			childNode = childNodes[i]
+			childNode.SetStatus(treeview.StatusRunning)
+		}
+		childCtx := execCtx.Copy()
+		childCtx.Depth = execCtx.Depth
+		childCtx.stepIDSuffix += parallelSuffix(i)
+		eg.Go(func() error {
+			return e.executeStepWithNode(ctx, childCtx, child, childNode)
+		})
+	}
+	err := eg.Wait()
+	duration := time.Since(startTime)
+
+	if execCtx.EventLogger != nil {
+		result := eventlog.ResultPass
+		if err != nil {
+			result = eventlog.ResultFail
+		}
+		execCtx.EventLogger.LogExec(result, stepID, stepName, startOffset, duration.Milliseconds(), err)
+	}
+
+	if stepNode != nil {
+		stepNode.SetDuration(duration.Seconds())
+		if err != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+		} else {
+			stepNode.SetStatus(treeview.StatusPassed)
+		}
+	}
+	return err
+}
+
+// stepCommands returns the commands of step. A step with use_snippet
+// runs the pipeline snippet of that name, interpolated like any command.
+func stepCommands(execCtx *ExecutionContext, step *model.Step) ([]string, error) {
+	if step.UseSnippet == "" {
+		return step.Commands(), nil
+	}
+
+	var snippets map[string]string
+	if execCtx.Pipeline != nil {
+		snippets = execCtx.Pipeline.Snippets
+	}
+	snippet, ok := snippets[step.UseSnippet]
+	if !ok {
+		return nil, fmt.Errorf("step uses snippet %q, which is not defined in snippets", step.UseSnippet)
+	}
+	return []string{strings.TrimSpace(snippet)}, nil
 }
 
 // executeStepWithForLoop handles for loop expansion and execution
@@ -453,6 +821,8 @@ func (e *Executor) executeStep(ctx context.Context, execCtx *ExecutionContext, s
 func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepIndex int, stepNode *treeview.Node) error {
 	// Expand the for loop to get all iterations
 	exec := NewExecWithEnv(execCtx.Env)
+	exec.Dir = execCtx.Dir
+	exec.Isolated = execCtx.EnvIsolated
 	iterations, err := ExpandFor(execCtx, exec.ExecuteCommand)
 	if err != nil {
 		if stepNode != nil {
@@ -471,6 +841,18 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 		return nil
 	}
 
+	// An invalid fail_threshold fails the step before any iteration runs
+	allowed := 0
+	if step.FailThreshold != "" {
+		allowed, err = failThreshold(step.FailThreshold, len(iterations))
+		if err != nil {
+			if stepNode != nil {
+				stepNode.SetStatus(treeview.StatusFailed)
+			}
+			return err
+		}
+	}
+
 	stepNode.Summarize = step.Summarize
 
 	// Build iteration nodes as children of the step node
@@ -492,10 +874,11 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 
 			var interpolated string
 			var nodeName string
-			// For task invocations, use the task name; otherwise interpolate the command
+			// For task invocations, use the task name with the loop variables;
+			// otherwise interpolate the command
 			if step.Task != "" {
 				interpolated = step.Task
-				nodeName = interpolated
+				nodeName = iterationLabel(interpolated, step.For, iteration.Variables)
 			} else {
 				var err error
 				interpolated, err = InterpolateCommand(cmdTemplate, iterCtx)
@@ -510,6 +893,16 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 				nodeName = interpolated
 			}
 
+			// A label replaces the command or task name
+			if step.IterLabel != "" {
+				var err error
+				nodeName, err = interpolateVariablesInString(step.IterLabel, iterCtx)
+				if err != nil {
+					stepNode.SetStatus(treeview.StatusFailed)
+					return fmt.Errorf("failed to interpolate label for iteration %d: %w", idx, err)
+				}
+			}
+
 			// Get job name for ID generation
 			jobName := ""
 			if execCtx.Job != nil {
@@ -517,8 +910,7 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 			}
 
 			// Generate unique ID for this iteration
-			iterSeqIndex := execCtx.StepSequence + idx
-			iterID := fmt.Sprintf("jobs.%s.steps.%d", jobName, iterSeqIndex)
+			iterID := generateStepID(jobName, execCtx.StepSequence) + execCtx.stepIDSuffix + iterationSuffix(idx)
 
 			iterNode := &treeview.Node{
 				Name:      nodeName,
@@ -552,13 +944,18 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 	execCtx.Render()
 
 	// Execute each iteration - use errgroup for detached (parallel) execution
+	detach, err := execCtx.stepDetached(step)
+	if err != nil {
+		return err
+	}
 	var eg *errgroup.Group
-	if step.Detach {
+	if detach {
 		eg = new(errgroup.Group)
 		eg.SetLimit(runtime.NumCPU())
 	}
 
 	var lastErr error
+	var failed int
 	var errMu sync.Mutex
 
 	for idx, iteration := range iterations {
@@ -569,6 +966,7 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 			// Create iteration context by overlaying iteration variables on parent context
 			iterCtx := execCtx.Copy()
 			iterCtx.Context = ctx
+			iterCtx.stepIDSuffix += iterationSuffix(idx)
 
 			// Overlay iteration variables (they override parent variables)
 			for k, v := range iteration.Variables {
@@ -595,19 +993,24 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 				}
 			} else {
 				// Execute all commands for this iteration
-				if err := e.executeCommands(ctx, iterCtx, step, iterNode, step.Commands(), stepIndex); err != nil {
+				commands, err := stepCommands(iterCtx, step)
+				if err != nil {
+					return err
+				}
+				if err := e.executeCommands(ctx, iterCtx, step, iterNode, commands, stepIndex); err != nil {
 					return err
 				}
 			}
 			return nil
 		}
 
-		if step.Detach {
+		if detach {
 			// Run iterations in parallel
 			eg.Go(func() error {
 				if err := executeIteration(); err != nil {
 					errMu.Lock()
 					lastErr = err
+					failed++
 					errMu.Unlock()
 					// Don't return error - continue collecting all failures
 				}
@@ -617,6 +1020,7 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 			// Run iterations sequentially
 			if err := executeIteration(); err != nil {
 				lastErr = err
+				failed++
 				// Continue to next iteration even on error (collect all failures)
 			}
 		}
@@ -627,6 +1031,19 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 		_ = eg.Wait()
 	}
 
+	if step.Summarize && stepNode != nil {
+		stepNode.SetStats(durationStats(iterationNodes))
+	}
+
+	// Failed iterations within the threshold don't fail the step
+	if lastErr != nil && step.FailThreshold != "" {
+		if failed <= allowed {
+			lastErr = nil
+		} else {
+			lastErr = fmt.Errorf("%d of %d iterations failed, over the fail_threshold of %s: %w", failed, len(iterations), step.FailThreshold, lastErr)
+		}
+	}
+
 	if stepNode != nil {
 		if lastErr != nil {
 			stepNode.SetStatus(treeview.StatusFailed)
@@ -640,6 +1057,59 @@ func (e *Executor) executeStepWithForLoop(ctx context.Context, execCtx *Executio
 	return nil
 }
 
+// failThreshold returns how many of total iterations may fail with the
+// fail_threshold, a count like `3` or a percentage like `10%`.
+func failThreshold(threshold string, total int) (int, error) {
+	if percent, ok := strings.CutSuffix(threshold, "%"); ok {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p < 0 {
+			return 0, fmt.Errorf("invalid fail_threshold %q: expected a count or a percentage", threshold)
+		}
+		return int(float64(total) * p / 100), nil
+	}
+	count, err := strconv.Atoi(threshold)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid fail_threshold %q: expected a count or a percentage", threshold)
+	}
+	return count, nil
+}
+
+// durationStats returns the min, median and max duration of the
+// iterations that ran, e.g. `min 0.1s, med 0.4s, max 3.2s`.
+func durationStats(iterationNodes []*treeview.Node) string {
+	durations := make([]float64, 0, len(iterationNodes))
+	for _, node := range iterationNodes {
+		if node.Status != treeview.StatusPassed && node.Status != treeview.StatusFailed {
+			continue
+		}
+		durations = append(durations, iterationDuration(node))
+	}
+	if len(durations) == 0 {
+		return ""
+	}
+
+	slices.Sort(durations)
+	n := len(durations)
+	median := durations[n/2]
+	if n%2 == 0 {
+		median = (durations[n/2-1] + durations[n/2]) / 2
+	}
+	return fmt.Sprintf("min %.1fs, med %.1fs, max %.1fs", durations[0], median, durations[n-1])
+}
+
+// iterationDuration returns the duration of an iteration node. Iterations
+// of cmds and tasks are timed on their child nodes.
+func iterationDuration(node *treeview.Node) float64 {
+	if node.Duration > 0 {
+		return node.Duration
+	}
+	var total float64
+	for _, child := range node.GetChildren() {
+		total += iterationDuration(child)
+	}
+	return total
+}
+
 // executeStepIteration executes a single step (or iteration of a step) with the given context
 func (e *Executor) executeStepIteration(ctx context.Context, stepCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node, cmd string, stepIndex int) error {
 	// Get step name for logging
@@ -656,7 +1126,7 @@ func (e *Executor) executeStepIteration(ctx context.Context, stepCtx *ExecutionC
 	if stepCtx.Job != nil {
 		jobName = stepCtx.Job.Name
 	}
-	stepID := generateStepID(jobName, seqIndex)
+	stepID := generateStepID(jobName, seqIndex) + stepCtx.stepIDSuffix
 
 	// Capture start offset for event log
 	var startOffset float64
@@ -675,8 +1145,14 @@ func (e *Executor) executeStepIteration(ctx context.Context, stepCtx *ExecutionC
 		stepCtx.Render()
 	}
 
+	// Capture stdout and stderr separately for the event log
+	var output *commandOutput
+	if stepCtx.EventLogger != nil {
+		output = &commandOutput{}
+	}
+
 	// Handle cmds: if step has multiple commands and child nodes exist, execute each command individually
-	err := e.executeCommand(ctx, stepCtx, step, cmd)
+	err := e.executeCommand(ctx, stepCtx, step, cmd, output)
 
 	// Calculate duration
 	duration := time.Since(startTime)
@@ -698,7 +1174,7 @@ func (e *Executor) executeStepIteration(ctx context.Context, stepCtx *ExecutionC
 		if err != nil {
 			result = eventlog.ResultFail
 		}
-		stepCtx.EventLogger.LogExec(result, stepID, stepName, startOffset, durationMs, err)
+		stepCtx.EventLogger.LogExecOutput(result, stepID, stepName, startOffset, durationMs, err, output.stdout.String(), output.stderr.String())
 	}
 
 	stepCtx.Render()
@@ -727,6 +1203,19 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 		return fmt.Errorf("task %q not found in pipeline", taskName)
 	}
 
+	// Guard against tasks invoking each other without end
+	chain, recursive := e.taskChain(execCtx, taskName)
+	maxDepth := e.opts.MaxTaskDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxTaskDepth
+	}
+	if len(chain)-1 > maxDepth {
+		if stepNode != nil {
+			stepNode.SetStatus(treeview.StatusFailed)
+		}
+		return fmt.Errorf("task recursion limit exceeded: %s", strings.Join(chain, " -> "))
+	}
+
 	// Execute dependencies first (if not already completed)
 	deps := GetDependencies(taskJob.DependsOn)
 	for _, depName := range deps {
@@ -743,7 +1232,10 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 
 		// Create a synthetic step to execute the dependency as a task
 		depStep := &model.Step{Task: depName}
-		if err := e.executeTaskStep(ctx, execCtx, depStep, stepNode); err != nil {
+		depCtx := execCtx.Copy()
+		depCtx.Depth = execCtx.Depth
+		depCtx.taskChain = chain
+		if err := e.executeTaskStep(ctx, depCtx, depStep, stepNode); err != nil {
 			return err
 		}
 	}
@@ -760,15 +1252,16 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 	taskJobNode.Summarize = taskJob.Summarize
 	stepNode.Summarize = step.Summarize
 
-	// Add task node as child of step node so it appears expanded in the tree
-	if stepNode != nil && taskJobNode != nil {
-		stepNode.AddChild(taskJobNode.Node)
-	}
-
-	// Check if this step has a for loop
+	// Check if this step has a for loop, each iteration gets a node of its own
 	if step.For != "" {
 		// Handle task invocation with for loop
-		return e.executeTaskStepWithLoop(ctx, execCtx, step, stepNode, taskJob, taskJobNode)
+		return e.executeTaskStepWithLoop(ctx, execCtx, step, stepNode, taskJob, taskJobNode, chain)
+	}
+
+	// Add task node as child of step node so it appears expanded in the tree.
+	// A recursive invocation reuses the node already in the tree above it.
+	if stepNode != nil && taskJobNode != nil && !recursive {
+		stepNode.AddChild(taskJobNode.Node)
 	}
 
 	// Mark the task as running
@@ -795,6 +1288,7 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 	taskCtx.CurrentJob = taskJobNode
 	taskCtx.Context = ctx
 	taskCtx.StepSequence = 0 // Reset step counter for new job
+	taskCtx.taskChain = chain
 
 	err := func() error {
 		if err := MergeVariables(taskJob.Decl, taskCtx); err != nil {
@@ -803,7 +1297,7 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 		if err := ValidateJobRequirements(taskJob, taskCtx); err != nil {
 			return err
 		}
-		if err := e.executeSteps(ctx, taskCtx, taskJob.Steps); err != nil {
+		if err := e.executeSteps(ctx, taskCtx, taskJob.Steps, nil); err != nil {
 			return err
 		}
 		return nil
@@ -840,12 +1334,26 @@ func (e *Executor) executeTaskStep(ctx context.Context, execCtx *ExecutionContex
 	return nil
 }
 
+// taskChain returns the chain of task invocations leading to taskName,
+// starting with the job that invoked the first task. It also reports
+// if taskName is already in the chain.
+func (e *Executor) taskChain(execCtx *ExecutionContext, taskName string) ([]string, bool) {
+	chain := execCtx.taskChain
+	if len(chain) == 0 && execCtx.Job != nil {
+		chain = []string{execCtx.Job.Name}
+	}
+	recursive := slices.Contains(chain, taskName)
+	return append(slices.Clone(chain), taskName), recursive
+}
+
 // executeTaskStepWithLoop executes a task multiple times via a for loop with loop variables
-func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node, taskJob *model.Job, taskJobNode *treeview.TreeNode) error {
+func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node, taskJob *model.Job, taskJobNode *treeview.TreeNode, chain []string) error {
 	defer execCtx.Render()
 
 	// Expand the for loop to get iteration contexts
 	exec := NewExecWithEnv(execCtx.Env)
+	exec.Dir = execCtx.Dir
+	exec.Isolated = execCtx.EnvIsolated
 	iterations, err := ExpandFor(execCtx, exec.ExecuteCommand)
 	if err != nil {
 		if stepNode != nil {
@@ -862,19 +1370,52 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 		return nil
 	}
 
+	// An invalid fail_threshold fails the step before any iteration runs
+	allowed := 0
+	if step.FailThreshold != "" {
+		allowed, err = failThreshold(step.FailThreshold, len(iterations))
+		if err != nil {
+			taskJobNode.SetStatus(treeview.StatusFailed)
+			if stepNode != nil {
+				stepNode.SetStatus(treeview.StatusFailed)
+			}
+			return err
+		}
+	}
+
+	// Each iteration runs the task steps under a job node of its own,
+	// labeled with the loop variables, e.g. `build (pkg=api)`
+	builder := treeview.NewBuilder(taskJob.Name)
+	iterNodes := make([]*treeview.TreeNode, len(iterations))
+	for idx, iter := range iterations {
+		iterNodes[idx] = builder.BuildJob(taskJob, iterationLabel(taskJob.Name, step.For, iter.Variables))
+		iterNodes[idx].ID = taskJobNode.ID + iterationSuffix(idx)
+		if stepNode != nil {
+			stepNode.AddChild(iterNodes[idx].Node)
+		}
+	}
+	execCtx.Render()
+
 	// Execute task for each iteration
 	var lastErr error
-	for _, iter := range iterations {
+	var failed int
+	for idx, iter := range iterations {
+		iterNode := iterNodes[idx]
+
 		// Create execution context for this iteration with loop variables
 		iterCtx := execCtx.Copy()
+		iterCtx.stepIDSuffix += iterationSuffix(idx)
+		iterCtx.StepSequence = 0 // Reset step counter for the task job
 		for k, v := range iter.Variables {
 			iterCtx.Variables[k] = v
 		}
 		iterCtx.Job = taskJob
-		iterCtx.CurrentJob = taskJobNode
+		iterCtx.CurrentJob = iterNode
 		iterCtx.Context = ctx
+		iterCtx.taskChain = chain
 
 		if err := MergeVariables(taskJob.Decl, iterCtx); err != nil {
+			iterNode.SetStatus(treeview.StatusFailed)
 			taskJobNode.SetStatus(treeview.StatusFailed)
 			if stepNode != nil {
 				stepNode.SetStatus(treeview.StatusFailed)
@@ -883,10 +1424,12 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 		}
 
 		// Mark task as running
+		iterNode.SetStatus(treeview.StatusRunning)
 		taskJobNode.SetStatus(treeview.StatusRunning)
 
 		// Validate job requirements (loop variables should satisfy requires)
 		if err := ValidateJobRequirements(taskJob, iterCtx); err != nil {
+			iterNode.SetStatus(treeview.StatusFailed)
 			taskJobNode.SetStatus(treeview.StatusFailed)
 			if stepNode != nil {
 				stepNode.SetStatus(treeview.StatusFailed)
@@ -895,10 +1438,23 @@ func (e *Executor) executeTaskStepWithLoop(ctx context.Context, execCtx *Executi
 		}
 
 		// Execute the task job steps with iteration context
-		if err := e.executeSteps(ctx, iterCtx, taskJob.Steps); err != nil {
+		if err := e.executeSteps(ctx, iterCtx, taskJob.Steps, nil); err != nil {
+			iterNode.SetStatus(treeview.StatusFailed)
 			lastErr = err
+			failed++
 			// Continue to next iteration even on error (collect all failures)
 			// This matches yamlexpr behavior of processing all items
+			continue
+		}
+		iterNode.SetStatus(treeview.StatusPassed)
+	}
+
+	// Failed iterations within the threshold don't fail the step
+	if lastErr != nil && step.FailThreshold != "" {
+		if failed <= allowed {
+			lastErr = nil
+		} else {
+			lastErr = fmt.Errorf("%d of %d iterations failed, over the fail_threshold of %s: %w", failed, len(iterations), step.FailThreshold, lastErr)
 		}
 	}
 
@@ -937,7 +1493,7 @@ func interpolateVariables(ctx *ExecutionContext, vars map[string]any) (map[strin
 	deps := make(map[string][]string)
 	for k, v := range vars {
 		if strVal, ok := v.(string); ok {
-			deps[k] = extractVariableDependencies(strVal, vars)
+			deps[k] = extractVariableDependencies(k, strVal, vars)
 		} else {
 			deps[k] = nil
 		}
@@ -951,8 +1507,13 @@ func interpolateVariables(ctx *ExecutionContext, vars map[string]any) (map[strin
 
 	// Create a working context that accumulates resolved variables
 	workCtx := &ExecutionContext{
-		Variables: make(map[string]any),
-		Env:       ctx.Env,
+		Variables:           make(map[string]any),
+		Env:                 ctx.Env,
+		Dir:                 ctx.Dir,
+		EnvIsolated:         ctx.EnvIsolated,
+		CommandCache:        ctx.CommandCache,
+		cacheCommands:       ctx.cacheCommands,
+		SubstitutionTimeout: ctx.SubstitutionTimeout,
 	}
 	for k, v := range ctx.Variables {
 		workCtx.Variables[k] = v
@@ -962,7 +1523,7 @@ func interpolateVariables(ctx *ExecutionContext, vars map[string]any) (map[strin
 	for _, k := range order {
 		v := vars[k]
 		if strVal, ok := v.(string); ok {
-			interpolated, err := InterpolateString(strVal, workCtx)
+			interpolated, err := InterpolateValue(strVal, workCtx)
 			if err != nil {
 				return nil, fmt.Errorf("failed to interpolate variable %q: %w", k, err)
 			}
@@ -987,7 +1548,8 @@ func countOutputLines(output string) int {
 	return count
 }
 
-// executeCmdsStep executes multiple commands as children of a step node
+// executeCmdsStep executes multiple commands as children of a step node.
+// It stops at the first failing command, unless step.Continue is set.
 func (e *Executor) executeCmdsStep(ctx context.Context, execCtx *ExecutionContext, step *model.Step, stepNode *treeview.Node) error {
 	children := stepNode.GetChildren()
 	var lastErr error
@@ -1011,13 +1573,23 @@ func (e *Executor) executeCmdsStep(ctx context.Context, execCtx *ExecutionContex
 			execCtx.CurrentStep = cmdNode
 		}
 
-		if err := e.executeCommand(ctx, execCtx, step, cmd); err != nil {
+		if err := e.executeCommand(ctx, execCtx, step, cmd, nil); err != nil {
 			if cmdNode != nil {
 				cmdNode.SetStatus(treeview.StatusFailed)
 			}
-			execCtx.Render()
 			lastErr = err
-			// Continue to next command even on error to update all nodes
+
+			// Stop at the first failure unless the step continues on errors
+			if !step.Continue {
+				execCtx.CurrentStep = originalStep
+				for _, skipped := range children[min(i+1, len(children)):] {
+					skipped.SetStatus(treeview.StatusSkipped)
+					skipped.SetSkipReason("previous command failed")
+				}
+				execCtx.Render()
+				break
+			}
+			execCtx.Render()
 		} else {
 			if cmdNode != nil {
 				cmdNode.SetStatus(treeview.StatusPassed)
@@ -1038,9 +1610,42 @@ func IsEchoCommand(cmd string) bool {
 	return strings.HasPrefix(trimmed, "echo ") && !strings.Contains(trimmed, "\n")
 }
 
+// outputAsLabel sets the first non-empty line of output as the node label,
+// and returns the remaining lines to keep as node output.
+func outputAsLabel(node *treeview.Node, lines []string) []string {
+	for idx, line := range lines {
+		if label := strings.TrimSpace(line); label != "" {
+			node.Name = label
+			return lines[idx+1:]
+		}
+	}
+	return nil
+}
+
+// commandOutput holds separately captured stdout and stderr of a command.
+type commandOutput struct {
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+// strictBash returns true if cmd should run with `set -euo pipefail`.
+// The step setting takes precedence over the job setting, by default
+// only multi-line scripts run in strict mode.
+func strictBash(step *model.Step, job *model.Job, cmd string) bool {
+	switch {
+	case step.StrictBash != nil:
+		return *step.StrictBash
+	case job != nil && job.StrictBash != nil:
+		return *job.StrictBash
+	}
+	return strings.Contains(strings.TrimSpace(cmd), "\n")
+}
+
 // evaluateEchoCommand executes an echo command and returns its output for use as a label
-func evaluateEchoCommand(ctx context.Context, cmd string, env map[string]string) (string, error) {
+func evaluateEchoCommand(ctx context.Context, cmd string, env map[string]string, dir string, isolated bool) (string, error) {
 	exec := NewExecWithEnv(env)
+	exec.Dir = dir
+	exec.Isolated = isolated
 	output, err := exec.ExecuteCommandWithQuiet(cmd, false)
 	if err != nil {
 		return "", err
@@ -1049,8 +1654,30 @@ func evaluateEchoCommand(ctx context.Context, cmd string, env map[string]string)
 	return strings.TrimSpace(output), nil
 }
 
+// openStdinFrom opens the stdin_from file of a step, interpolating the
+// path and resolving it relative to the pipeline file.
+func openStdinFrom(stdinFrom string, execCtx *ExecutionContext) (*os.File, error) {
+	path, err := InterpolateString(stdinFrom, execCtx)
+	if err != nil {
+		return nil, fmt.Errorf("stdin_from interpolation failed: %w", err)
+	}
+	if !filepath.IsAbs(path) && execCtx.Pipeline != nil {
+		path = filepath.Join(execCtx.Pipeline.Dir, path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("stdin_from file %s doesn't exist", path)
+		}
+		return nil, fmt.Errorf("stdin_from: %w", err)
+	}
+	return f, nil
+}
+
 // executeCommand runs a single command with interpolation and respects context timeout
-func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext, step *model.Step, cmd string) error {
+// If output is not nil, stdout and stderr are additionally captured into it.
+func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext, step *model.Step, cmd string, output *commandOutput) error {
 	// Interpolate the command
 	interpolated, err := InterpolateCommand(cmd, execCtx)
 	if err != nil {
@@ -1068,23 +1695,64 @@ func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext
 
 	// Execute the command via bash with quiet mode, passing execution context env
 	exec := NewExecWithEnv(execCtx.Env)
+	exec.Dir = execCtx.Dir
+	exec.Isolated = execCtx.EnvIsolated
+	exec.Context = ctx
+	exec.Strict = strictBash(step, execCtx.Job, interpolated)
+	if step.Stdin != "" {
+		exec.Stdin, err = InterpolateString(step.Stdin, execCtx)
+		if err != nil {
+			return fmt.Errorf("stdin interpolation failed: %w", err)
+		}
+	}
+	if step.StdinFrom != "" {
+		stdin, err := openStdinFrom(step.StdinFrom, execCtx)
+		if err != nil {
+			return err
+		}
+		defer stdin.Close()
+		exec.StdinFrom = stdin
+	}
+	if output != nil {
+		exec.Stdout = &output.stdout
+		exec.Stderr = &output.stderr
+	}
 
 	// Determine if output should be captured for display with tree indentation
 	// Check step passthru flag first, then job passthru flag
-	shouldPassthru := step.Passthru || (execCtx.Job != nil && execCtx.Job.Passthru)
+	terminal := execCtx.Display != nil && execCtx.Display.IsTerminal()
+	shouldPassthru := step.Passthru.Enabled(terminal) || (execCtx.Job != nil && execCtx.Job.Passthru.Enabled(terminal))
 
 	// Determine TTY allocation: Job.TTY is authoritative, otherwise use Step.TTY
 	useTTY := step.TTY || (execCtx.Job != nil && execCtx.Job.TTY)
 
-	// If passthru is enabled, capture output to the node for display with tree indentation
+	// If passthru is enabled, capture output to the node for display with tree indentation.
+	// Output used as the label is captured the same way.
 	var writer *LineCapturingWriter
-	if shouldPassthru && execCtx.CurrentStep != nil {
+	if (shouldPassthru || step.AsLabel) && execCtx.CurrentStep != nil {
 		writer = NewLineCapturingWriter()
 		_, err = exec.ExecuteCommandWithWriter(writer, interpolated, useTTY)
 	} else {
 		_, err = exec.ExecuteCommandWithQuiet(interpolated, execCtx.Verbose)
 	}
 
+	// Set captured output on the node, failed steps keep their output visible
+	if writer != nil {
+		lines, sanitizeErr := Sanitize(writer.String())
+		if sanitizeErr != nil && err == nil {
+			return fmt.Errorf("failed to sanitize output: %w", sanitizeErr)
+		}
+		lines = execCtx.Redactor.RedactLines(lines)
+		if step.AsLabel && err == nil {
+			// The lines after the label are part of it, they aren't folded
+			lines = outputAsLabel(execCtx.CurrentStep, lines)
+			execCtx.CurrentStep.KeepOutput = true
+		}
+		if len(lines) > 0 {
+			execCtx.CurrentStep.SetOutput(lines)
+		}
+	}
+
 	if err != nil {
 		// Return the error as-is if it's an ExecError, otherwise wrap it
 		if execErr, ok := err.(ExecError); ok {
@@ -1094,22 +1762,10 @@ func (e *Executor) executeCommand(ctx context.Context, execCtx *ExecutionContext
 	}
 
 	// For echo commands, update the step node label with the output
-	if IsEchoCommand(interpolated) && execCtx.CurrentStep != nil {
-		output, err := evaluateEchoCommand(ctx, interpolated, execCtx.Env)
+	if IsEchoCommand(interpolated) && execCtx.CurrentStep != nil && step.IterLabel == "" && !step.AsLabel {
+		output, err := evaluateEchoCommand(ctx, interpolated, execCtx.Env, execCtx.Dir, execCtx.EnvIsolated)
 		if err == nil && output != "" {
-			execCtx.CurrentStep.Name = output
-		}
-	}
-
-	// Set output on node only after command completes successfully
-	if writer != nil && execCtx.CurrentStep != nil {
-		rawOutput := writer.String()
-		lines, sanitizeErr := Sanitize(rawOutput)
-		if sanitizeErr != nil {
-			return fmt.Errorf("failed to sanitize output: %w", sanitizeErr)
-		}
-		if len(lines) > 0 {
-			execCtx.CurrentStep.SetOutput(lines)
+			execCtx.CurrentStep.Name = execCtx.Redactor.Redact(output)
 		}
 	}
 
@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// pathsExist returns true if every glob pattern matches at least one path.
+// Relative patterns are resolved against dir.
+func pathsExist(dir string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid if_exists pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Failure is the captured output of a failed step.
+type Failure struct {
+	ID     string
+	Name   string
+	Output []string
+}
+
+// CollectFailures walks the tree for failed steps with output, in tree
+// order. Output shown on the node is used if set, otherwise the stdout
+// and stderr of the step's last logged event.
+func CollectFailures(root *treeview.Node, events []*eventlog.Event) []Failure {
+	byID := make(map[string]*eventlog.Event, len(events))
+	for _, event := range events {
+		if event.Result == eventlog.ResultFail {
+			byID[event.ID] = event
+		}
+	}
+
+	var failures []Failure
+	var walk func(node *treeview.Node)
+	walk = func(node *treeview.Node) {
+		children := node.GetChildren()
+		if len(children) == 0 && node.Status == treeview.StatusFailed {
+			output := node.Output
+			if len(output) == 0 {
+				if event, ok := byID[node.ID]; ok {
+					output = outputLines(event.Stdout + "\n" + event.Stderr)
+				}
+			}
+			if len(output) > 0 {
+				failures = append(failures, Failure{ID: node.ID, Name: node.Name, Output: output})
+			}
+		}
+		for _, child := range children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return failures
+}
+
+// WriteFailures prints failures in a Failures section, grouped by step ID.
+func WriteFailures(w io.Writer, failures []Failure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\n", colors.BrightRed("Failures:"))
+	for _, failure := range failures {
+		fmt.Fprintf(w, "\n  %s %s\n", colors.BrightRed(failure.ID), colors.Gray(failure.Name))
+		for _, line := range failure.Output {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+// outputLines splits output into lines, leaving out empty ones.
+func outputLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,237 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// Graph entity types.
+const (
+	GraphPipeline = "pipeline"
+	GraphJob      = "job"
+	GraphStep     = "step"
+	GraphTask     = "task"
+)
+
+// graphColors are the fill colors of graph entity types.
+var graphColors = map[string]string{
+	GraphPipeline: "lightblue",
+	GraphJob:      "lightgreen",
+	GraphTask:     "khaki",
+	GraphStep:     "whitesmoke",
+}
+
+// GraphNode is an entity in the execution graph.
+type GraphNode struct {
+	ID    string
+	Label string
+	Type  string
+}
+
+// GraphEdge is a relation between two graph nodes.
+type GraphEdge struct {
+	From  string
+	To    string
+	Label string
+}
+
+// Graph is the execution graph of a pipeline, built statically from the
+// pipeline definition by BuildGraph, or from the run tree of an event log.
+type Graph struct {
+	Name  string
+	Nodes []*GraphNode
+	Edges []*GraphEdge
+}
+
+// BuildGraph builds the execution graph from a pipeline definition.
+// Task invocations link to the invoked job, for loops are expanded
+// to an iteration count where the items are statically known.
+func BuildGraph(pipeline *model.Pipeline) *Graph {
+	jobs := pipeline.Jobs
+	if len(jobs) == 0 {
+		jobs = pipeline.Tasks
+	}
+
+	g := &Graph{Name: pipeline.Name}
+	g.AddNode(GraphPipeline, pipeline.Name, GraphPipeline)
+
+	jobNames := make([]string, 0, len(jobs))
+	for name := range jobs {
+		jobNames = append(jobNames, name)
+	}
+	sort.Strings(jobNames)
+
+	for _, name := range jobNames {
+		g.AddNode(graphJobID(name), name, GraphJob)
+	}
+
+	for _, name := range jobNames {
+		job := jobs[name]
+		jobID := graphJobID(name)
+
+		if job.IsRootLevel() {
+			g.AddEdge(GraphPipeline, jobID, "job")
+		}
+		for _, dep := range job.DependsOn {
+			if _, ok := jobs[dep]; ok {
+				g.AddEdge(graphJobID(dep), jobID, "depends_on")
+			}
+		}
+
+		prevID := jobID
+		for idx, step := range job.Children() {
+			stepID := jobID + "/step." + strconv.Itoa(idx)
+			label := step.DisplayLabel()
+			if step.For != "" {
+				label += " " + staticForLabel(pipeline, job, step)
+			}
+
+			if step.Task != "" {
+				g.AddNode(stepID, label, GraphTask)
+				if _, ok := jobs[step.Task]; ok {
+					g.AddEdge(stepID, graphJobID(step.Task), "invokes")
+				}
+			} else {
+				g.AddNode(stepID, label, GraphStep)
+			}
+
+			if prevID == jobID {
+				g.AddEdge(jobID, stepID, "step."+strconv.Itoa(idx))
+			} else {
+				g.AddEdge(prevID, stepID, "next")
+			}
+			prevID = stepID
+		}
+	}
+
+	return g
+}
+
+// WriteDOT writes the graph in graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", strconv.Quote(g.Name))
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  node [shape=box, style=filled];\n")
+	sb.WriteString("\n")
+
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "  %s [label=%s, fillcolor=%s];\n", strconv.Quote(n.ID), strconv.Quote(n.Label), graphColors[n.Type])
+	}
+	sb.WriteString("\n")
+
+	for _, e := range g.Edges {
+		style := ""
+		if e.Label == "invokes" {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), strconv.Quote(e.Label), style)
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WritePlantUML writes the graph as a PlantUML component diagram.
+func (g *Graph) WritePlantUML(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("@startuml\n")
+	fmt.Fprintf(&sb, "title %s\n", g.Name)
+	sb.WriteString("\n")
+	sb.WriteString("skinparam componentStyle rectangle\n")
+	sb.WriteString("skinparam defaultTextAlignment center\n")
+	sb.WriteString("top to bottom direction\n")
+	sb.WriteString("\n")
+
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "component %s as %s #%s\n", strconv.Quote(n.Label), plantUMLAlias(n.ID), graphColors[n.Type])
+	}
+	sb.WriteString("\n")
+	sb.WriteString("' Relationships\n")
+
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Label == "invokes" {
+			arrow = "..>"
+		}
+		fmt.Fprintf(&sb, "%s %s %s\n", plantUMLAlias(e.From), arrow, plantUMLAlias(e.To))
+	}
+	sb.WriteString("\n")
+	sb.WriteString("@enduml\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// AddNode adds an entity of the given type to the graph.
+func (g *Graph) AddNode(id, label, typ string) {
+	g.Nodes = append(g.Nodes, &GraphNode{ID: id, Label: label, Type: typ})
+}
+
+// AddEdge adds a labeled relation between two graph nodes.
+func (g *Graph) AddEdge(from, to, label string) {
+	g.Edges = append(g.Edges, &GraphEdge{From: from, To: to, Label: label})
+}
+
+// Node returns the graph node with the ID, or nil if there is none.
+func (g *Graph) Node(id string) *GraphNode {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// plantUMLAlias replaces the characters PlantUML doesn't allow in an alias.
+func plantUMLAlias(id string) string {
+	var sb strings.Builder
+	for _, r := range id {
+		switch {
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+// graphJobID returns the graph node ID for a job.
+func graphJobID(name string) string {
+	return "job:" + name
+}
+
+// staticForLabel describes a for loop, with the iteration count if the
+// items can be resolved without running commands.
+func staticForLabel(pipeline *model.Pipeline, job *model.Job, step *model.Step) string {
+	vars := make(map[string]any)
+	for _, decl := range []*model.Decl{pipeline.Decl, job.Decl, step.Decl} {
+		if decl == nil {
+			continue
+		}
+		for k, v := range decl.Vars {
+			vars[k] = v
+		}
+	}
+
+	ctx := &ExecutionContext{
+		Variables: vars,
+		Env:       map[string]string{},
+		Step:      step,
+	}
+	iterations, err := ExpandFor(ctx, func(string) (string, error) {
+		return "", fmt.Errorf("command expansion is not static")
+	})
+	if err != nil {
+		return "(for: " + step.For + ")"
+	}
+	return fmt.Sprintf("(%d iterations)", len(iterations))
+}
@@ -0,0 +1,85 @@
+package runner_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func hasEdge(g *runner.Graph, from, to string) bool {
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBuildGraph(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: graph
+vars:
+  targets: [amd64, arm64]
+jobs:
+  build:
+    steps:
+      - run: go build ./...
+      - task: build:docker
+        for: target in targets
+  build:docker:
+    steps:
+      - run: docker build .
+  test:
+    depends_on: build
+    steps:
+      - run: go test ./...
+`)
+
+	g := runner.BuildGraph(pipeline)
+
+	t.Run("pipeline to job", func(t *testing.T) {
+		assert.True(t, hasEdge(g, "pipeline", "job:build"))
+		assert.True(t, hasEdge(g, "pipeline", "job:test"))
+		assert.False(t, hasEdge(g, "pipeline", "job:build:docker"))
+		assert.True(t, hasEdge(g, "job:build", "job:test"))
+	})
+
+	t.Run("step to task", func(t *testing.T) {
+		assert.True(t, hasEdge(g, "job:build", "job:build/step.0"))
+		assert.True(t, hasEdge(g, "job:build/step.0", "job:build/step.1"))
+		assert.True(t, hasEdge(g, "job:build/step.1", "job:build:docker"))
+
+		var label string
+		for _, n := range g.Nodes {
+			if n.ID == "job:build/step.1" {
+				label = n.Label
+			}
+		}
+		assert.Equal(t, "task: build:docker (2 iterations)", label)
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, g.WriteDOT(&buf))
+
+		out := buf.String()
+		assert.Contains(t, out, `digraph "graph" {`)
+		assert.Contains(t, out, `"pipeline" -> "job:build" [label="job"];`)
+		assert.Contains(t, out, `"job:build/step.1" -> "job:build:docker" [label="invokes", style=dashed];`)
+	})
+
+	t.Run("plantuml", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, g.WritePlantUML(&buf))
+
+		out := buf.String()
+		assert.Contains(t, out, "title graph\n")
+		assert.Contains(t, out, `component "build:docker" as job_build_docker #lightgreen`)
+		assert.Contains(t, out, "pipeline --> job_build\n")
+		assert.Contains(t, out, "job_build_step_1 ..> job_build_docker\n")
+	})
+}
@@ -1,13 +1,19 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/expr-lang/expr"
 )
 
+// DefaultSubstitutionTimeout limits how long a $(...) command substitution may run.
+const DefaultSubstitutionTimeout = 30 * time.Second
+
 // Matches ${{ variable_name }}
 var interpolationRegex = regexp.MustCompile(`\$\{\{\s*([^}]+?)\s*\}\}`)
 
@@ -49,6 +55,22 @@ func InterpolateString(s string, ctx *ExecutionContext) (string, error) {
 	return result, nil
 }
 
+// Matches a value that consists of a single ${{ expression }}
+var wholeExpressionRegex = regexp.MustCompile(`^\s*\$\{\{\s*([^}]+?)\s*\}\}\s*$`)
+
+// InterpolateValue interpolates s like InterpolateString, except when the
+// whole value is a single ${{ expression }}. In that case the evaluated
+// value is returned as-is, so lists and maps keep their type.
+func InterpolateValue(s string, ctx *ExecutionContext) (any, error) {
+	if m := wholeExpressionRegex.FindStringSubmatch(s); m != nil {
+		val, err := evaluateExpression(strings.TrimSpace(m[1]), ctx)
+		if err == nil && val != nil {
+			return val, nil
+		}
+	}
+	return InterpolateString(s, ctx)
+}
+
 // extractAndProcessCommandSubstitutions handles $(...) by properly matching nested parentheses
 func extractAndProcessCommandSubstitutions(s string, ctx *ExecutionContext, cmdErr *error) string {
 	if *cmdErr != nil {
@@ -85,19 +107,47 @@ func extractAndProcessCommandSubstitutions(s string, ctx *ExecutionContext, cmdE
 			// Uncomment to debug interpolation issues
 			// fmt.Fprintf(os.Stderr, "DEBUG: Executing command: %q\n", interpolatedCmd)
 
+			// Reuse memoized output when caching is enabled for this declaration
+			if ctx.cacheCommands {
+				if output, ok := ctx.CommandCache.Get(interpolatedCmd); ok {
+					result += output
+					i = closeIdx + 1
+					continue
+				}
+			}
+
 			// Execute with context env variables
 			exec := NewExecWithEnv(ctx.Env)
+			exec.Dir = ctx.Dir
+			exec.Isolated = ctx.EnvIsolated
+			timeout := ctx.SubstitutionTimeout
+			if timeout <= 0 {
+				timeout = DefaultSubstitutionTimeout
+			}
+			parent := ctx.Context
+			if parent == nil {
+				parent = context.Background()
+			}
+			execCtx, cancel := context.WithTimeout(parent, timeout)
+			exec.Context = execCtx
 			output, err := exec.ExecuteCommand(interpolatedCmd)
+			cancel()
 			if err != nil {
 				// Capture error with better context showing what command was executed
-				if execErr, ok := err.(ExecError); ok {
+				if errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+					*cmdErr = fmt.Errorf("command substitution $(%s) timed out after %s", interpolatedCmd, timeout)
+				} else if execErr, ok := err.(ExecError); ok {
 					*cmdErr = fmt.Errorf("command execution failed: %s\nCommand: %s", execErr.Output, interpolatedCmd)
 				} else {
 					*cmdErr = fmt.Errorf("command execution failed in $(%s): %w", interpolatedCmd, err)
 				}
 				return s
 			}
-			result += strings.TrimSpace(output)
+			output = strings.TrimSpace(output)
+			if ctx.cacheCommands {
+				ctx.CommandCache.Set(interpolatedCmd, output)
+			}
+			result += output
 			i = closeIdx + 1
 		} else {
 			result += string(s[i])
@@ -223,8 +273,17 @@ func InterpolateCommand(cmd string, ctx *ExecutionContext) (string, error) {
 // Note: The ?? (null coalescing) operator is the preferred pattern for defaults
 // since it explicitly handles nil/missing values without side effects on falsy values.
 func evaluateExpression(exprStr string, ctx *ExecutionContext) (any, error) {
-	// Merge variables and environment into a single map for expr evaluation
+	return evaluateExpressionWith(exprStr, ctx, nil)
+}
+
+// evaluateExpressionWith evaluates exprStr with functions available
+// in addition to the variables and environment of ctx.
+func evaluateExpressionWith(exprStr string, ctx *ExecutionContext, functions map[string]any) (any, error) {
+	// Merge functions, variables and environment into a single map for expr evaluation
 	env := make(map[string]any)
+	for k, v := range functions {
+		env[k] = v
+	}
 	for k, v := range ctx.Variables {
 		env[k] = v
 	}
@@ -233,7 +292,7 @@ func evaluateExpression(exprStr string, ctx *ExecutionContext) (any, error) {
 	}
 
 	// Compile and evaluate the expression
-	program, err := expr.Compile(exprStr)
+	program, err := expr.Compile(exprStr, numericOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile expression: %w", err)
 	}
@@ -2,6 +2,7 @@ package runner_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/expr-lang/expr"
 	"github.com/stretchr/testify/assert"
@@ -342,3 +343,45 @@ func evaluateExpr(exprStr string, ctx *runner.ExecutionContext) (any, error) {
 	}
 	return expr.Run(program, env)
 }
+
+func TestInterpolateValue(t *testing.T) {
+	ctx := &runner.ExecutionContext{
+		Variables: map[string]any{
+			"list": []any{"a", "b"},
+			"name": "atkins",
+		},
+		Env: map[string]string{},
+	}
+
+	t.Run("whole expression keeps type", func(t *testing.T) {
+		val, err := runner.InterpolateValue("${{ list }}", ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []any{"a", "b"}, val)
+	})
+
+	t.Run("embedded expression is stringified", func(t *testing.T) {
+		val, err := runner.InterpolateValue("items: ${{ list }}", ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "items: [a b]", val)
+	})
+
+	t.Run("unresolved expression is kept", func(t *testing.T) {
+		val, err := runner.InterpolateValue("${{ missing }}", ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "${{ missing }}", val)
+	})
+}
+
+func TestInterpolateString_SubstitutionTimeout(t *testing.T) {
+	ctx := &runner.ExecutionContext{
+		Variables:           map[string]any{},
+		Env:                 map[string]string{},
+		SubstitutionTimeout: 100 * time.Millisecond,
+	}
+
+	start := time.Now()
+	_, err := runner.InterpolateString("value: $(sleep 5)", ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "$(sleep 5) timed out after 100ms")
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
@@ -3,23 +3,45 @@ package runner
 import (
 	"fmt"
 	"maps"
+	"os/exec"
 	"slices"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
 
 	"github.com/titpetric/atkins/model"
 	"github.com/titpetric/atkins/treeview"
 )
 
+// Severity of a lint issue.
+type Severity string
+
+// Lint severities. Warnings are reported but don't invalidate the pipeline.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
 // LintError represents a linting error.
 type LintError struct {
-	Job    string
-	Issue  string
-	Detail string
+	Job      string
+	Issue    string
+	Detail   string
+	Severity Severity
+}
+
+// IsWarning returns true if the issue is a warning.
+func (e LintError) IsWarning() bool {
+	return e.Severity == SeverityWarning
 }
 
 // Linter validates a pipeline for correctness.
 type Linter struct {
 	pipeline *model.Pipeline
 	errors   []LintError
+	ignore   []string
 }
 
 // NewLinter creates a new linter.
@@ -30,11 +52,181 @@ func NewLinter(pipeline *model.Pipeline) *Linter {
 	}
 }
 
+// Ignore suppresses warnings by issue, e.g. "shadowed variable".
+// Dashes match spaces, so "shadowed-variable" works as well.
+func (l *Linter) Ignore(issues ...string) {
+	for _, issue := range issues {
+		l.ignore = append(l.ignore, strings.ReplaceAll(issue, "-", " "))
+	}
+}
+
 // Lint validates the pipeline and returns any errors.
 func (l *Linter) Lint() []LintError {
 	l.validateDependencies()
 	l.validateTaskInvocations()
-	return l.errors
+	l.validateSnippets()
+	l.validateTaskRecursion()
+	l.validateConditions()
+	l.validateShadowedVars()
+	l.validateFailThresholds()
+
+	return slices.DeleteFunc(l.errors, func(lintErr LintError) bool {
+		return lintErr.IsWarning() && slices.Contains(l.ignore, lintErr.Issue)
+	})
+}
+
+// validateShadowedVars warns about job and step vars that redefine a
+// var of the same name from the pipeline or job scope.
+func (l *Linter) validateShadowedVars() {
+	jobs := l.pipeline.Jobs
+	if len(jobs) == 0 {
+		jobs = l.pipeline.Tasks
+	}
+
+	pipelineVars := declVars(l.pipeline.Decl)
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[jobName]
+		if job == nil {
+			continue
+		}
+
+		jobVars := declVars(job.Decl)
+		for _, name := range slices.Sorted(maps.Keys(jobVars)) {
+			if _, ok := pipelineVars[name]; ok {
+				l.errors = append(l.errors, LintError{
+					Job:      jobName,
+					Issue:    "shadowed variable",
+					Detail:   fmt.Sprintf("job var %q shadows pipeline var", name),
+					Severity: SeverityWarning,
+				})
+			}
+		}
+
+		for idx, step := range job.Children() {
+			if step == nil {
+				continue
+			}
+			for _, name := range slices.Sorted(maps.Keys(declVars(step.Decl))) {
+				scope := ""
+				if _, ok := jobVars[name]; ok {
+					scope = "job"
+				} else if _, ok := pipelineVars[name]; ok {
+					scope = "pipeline"
+				}
+				if scope != "" {
+					l.errors = append(l.errors, LintError{
+						Job:      jobName,
+						Issue:    "shadowed variable",
+						Detail:   fmt.Sprintf("step %d var %q shadows %s var", idx, name, scope),
+						Severity: SeverityWarning,
+					})
+				}
+			}
+		}
+	}
+}
+
+// validateFailThresholds checks fail_threshold values and warns about
+// steps without a for loop, where fail_threshold has no effect.
+func (l *Linter) validateFailThresholds() {
+	jobs := l.pipeline.Jobs
+	if len(jobs) == 0 {
+		jobs = l.pipeline.Tasks
+	}
+
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[jobName]
+		if job == nil {
+			continue
+		}
+
+		for idx, step := range job.Children() {
+			if step == nil || step.FailThreshold == "" {
+				continue
+			}
+			if _, err := failThreshold(step.FailThreshold, 0); err != nil {
+				l.errors = append(l.errors, LintError{
+					Job:      jobName,
+					Issue:    "invalid fail_threshold",
+					Detail:   fmt.Sprintf("step %d: %s", idx, err),
+					Severity: SeverityError,
+				})
+			}
+			if step.For == "" {
+				l.errors = append(l.errors, LintError{
+					Job:      jobName,
+					Issue:    "unused fail_threshold",
+					Detail:   fmt.Sprintf("step %d sets fail_threshold without for, it has no effect", idx),
+					Severity: SeverityWarning,
+				})
+			}
+		}
+	}
+}
+
+// declVars returns the vars of decl, or nil if there is no decl.
+func declVars(decl *model.Decl) map[string]any {
+	if decl == nil {
+		return nil
+	}
+	return decl.Vars
+}
+
+// validateConditions warns about steps with an if condition that is constant false.
+func (l *Linter) validateConditions() {
+	jobs := l.pipeline.Jobs
+	if len(jobs) == 0 {
+		jobs = l.pipeline.Tasks
+	}
+
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[jobName]
+		if job == nil {
+			continue
+		}
+
+		for idx, step := range job.Children() {
+			if step == nil || step.If == "" {
+				continue
+			}
+			if isConstantFalse(step.If) {
+				l.errors = append(l.errors, LintError{
+					Job:      jobName,
+					Issue:    "unreachable step",
+					Detail:   fmt.Sprintf("step %d condition %q is always false, step will never run", idx, step.If),
+					Severity: SeverityWarning,
+				})
+			}
+		}
+	}
+}
+
+// isConstantFalse returns true if the condition uses no variables or
+// functions and evaluates to false. It's best-effort; anything that
+// can't be evaluated statically is not reported.
+func isConstantFalse(condition string) bool {
+	tree, err := parser.Parse(condition)
+	if err != nil {
+		return false
+	}
+
+	dynamic := ast.Find(tree.Node, func(node ast.Node) bool {
+		_, ok := node.(*ast.IdentifierNode)
+		return ok
+	})
+	if dynamic != nil {
+		return false
+	}
+
+	program, err := expr.Compile(condition)
+	if err != nil {
+		return false
+	}
+	result, err := expr.Run(program, map[string]any{})
+	if err != nil {
+		return false
+	}
+	return !isTruthy(result)
 }
 
 // validateDependencies checks that all depends_on references exist
@@ -44,7 +236,8 @@ func (l *Linter) validateDependencies() {
 		jobs = l.pipeline.Tasks
 	}
 
-	for jobName, job := range jobs {
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[jobName]
 		if job == nil {
 			continue
 		}
@@ -53,9 +246,10 @@ func (l *Linter) validateDependencies() {
 		for _, dep := range deps {
 			if _, exists := jobs[dep]; !exists {
 				l.errors = append(l.errors, LintError{
-					Job:    jobName,
-					Issue:  "missing dependency",
-					Detail: fmt.Sprintf("job '%s' depends_on '%s', but job '%s' not found", jobName, dep, dep),
+					Job:      jobName,
+					Issue:    "missing dependency",
+					Detail:   fmt.Sprintf("job '%s' depends_on '%s', but job '%s' not found", jobName, dep, dep),
+					Severity: SeverityError,
 				})
 			}
 		}
@@ -69,7 +263,8 @@ func (l *Linter) validateTaskInvocations() {
 		jobs = l.pipeline.Tasks
 	}
 
-	for jobName, job := range jobs {
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[jobName]
 		if job == nil {
 			continue
 		}
@@ -79,9 +274,92 @@ func (l *Linter) validateTaskInvocations() {
 			if step != nil && step.Task != "" {
 				if _, exists := jobs[step.Task]; !exists {
 					l.errors = append(l.errors, LintError{
-						Job:    jobName,
-						Issue:  "missing task reference",
-						Detail: fmt.Sprintf("step references task '%s', but task not found", step.Task),
+						Job:      jobName,
+						Issue:    "missing task reference",
+						Detail:   fmt.Sprintf("step references task '%s', but task not found", step.Task),
+						Severity: SeverityError,
+					})
+				}
+			}
+		}
+	}
+}
+
+// validateTaskRecursion reports tasks that invoke themselves, directly or
+// through other tasks, with steps that have no if condition to end it.
+func (l *Linter) validateTaskRecursion() {
+	jobs := l.pipeline.Jobs
+	if len(jobs) == 0 {
+		jobs = l.pipeline.Tasks
+	}
+
+	// invokes returns the tasks a job always invokes
+	invokes := func(job *model.Job) []string {
+		var tasks []string
+		for _, step := range job.Children() {
+			if step != nil && step.Task != "" && step.If == "" {
+				tasks = append(tasks, step.Task)
+			}
+		}
+		return tasks
+	}
+
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		visited := map[string]bool{}
+		var cycle func(name string, path []string) []string
+		cycle = func(name string, path []string) []string {
+			job := jobs[name]
+			if job == nil || visited[name] {
+				return nil
+			}
+			visited[name] = true
+
+			for _, task := range invokes(job) {
+				if task == jobName {
+					return append(path, task)
+				}
+				if found := cycle(task, append(path, task)); found != nil {
+					return found
+				}
+			}
+			return nil
+		}
+
+		// Report each cycle once, for the job that sorts first
+		found := cycle(jobName, []string{jobName})
+		if found == nil || slices.Min(found) != jobName {
+			continue
+		}
+		l.errors = append(l.errors, LintError{
+			Job:      jobName,
+			Issue:    "task recursion",
+			Detail:   fmt.Sprintf("task invokes itself without an if condition: %s", strings.Join(found, " -> ")),
+			Severity: SeverityError,
+		})
+	}
+}
+
+// validateSnippets checks that steps only use snippets that are defined.
+func (l *Linter) validateSnippets() {
+	jobs := l.pipeline.Jobs
+	if len(jobs) == 0 {
+		jobs = l.pipeline.Tasks
+	}
+
+	for _, jobName := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[jobName]
+		if job == nil {
+			continue
+		}
+
+		for _, step := range job.Children() {
+			if step != nil && step.UseSnippet != "" {
+				if _, exists := l.pipeline.Snippets[step.UseSnippet]; !exists {
+					l.errors = append(l.errors, LintError{
+						Job:      jobName,
+						Issue:    "missing snippet reference",
+						Detail:   fmt.Sprintf("step uses snippet '%s', but snippet not found", step.UseSnippet),
+						Severity: SeverityError,
 					})
 				}
 			}
@@ -214,6 +492,49 @@ func ValidateJobRequirements(job *model.Job, ctx *ExecutionContext) error {
 	return nil
 }
 
+// ValidatePipelineRequirements checks that all variables or environment variables
+// required by the pipeline are present in the context.
+// Returns an error with a clear message listing missing requirements.
+func ValidatePipelineRequirements(pipeline *model.Pipeline, ctx *ExecutionContext) error {
+	if len(pipeline.Requires) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, name := range pipeline.Requires {
+		if _, exists := ctx.Variables[name]; exists {
+			continue
+		}
+		if _, exists := ctx.Env[name]; exists {
+			continue
+		}
+		missing = append(missing, name)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("pipeline '%s' requires %v but missing: %v", pipeline.Name, pipeline.Requires, missing)
+	}
+
+	return nil
+}
+
+// ValidatePipelineTools checks that all tools required by the pipeline
+// are found on PATH. Returns an error listing the missing tools.
+func ValidatePipelineTools(pipeline *model.Pipeline) error {
+	var missing []string
+	for _, tool := range pipeline.RequiresTools {
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("pipeline '%s' requires tools %v but missing on PATH: %v", pipeline.Name, pipeline.RequiresTools, missing)
+	}
+
+	return nil
+}
+
 // resolveJobs returns all jobs in dependency order (topological sort)
 // When called without a specific job, only root jobs are traversed as starting points,
 // but their nested dependencies are included in the result.
@@ -0,0 +1,175 @@
+package runner_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestLinter_ConstantFalseConditions(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: conditions
+vars:
+  enabled: false
+jobs:
+  default:
+    steps:
+      - run: echo never
+        if: "false"
+      - run: echo math
+        if: 1 == 2
+      - run: echo variable
+        if: enabled
+      - run: echo status
+        if: failure()
+      - run: echo always
+        if: 1 == 1
+`)
+
+	lintErrors := runner.NewLinter(pipeline).Lint()
+	require.Len(t, lintErrors, 2)
+
+	for _, lintErr := range lintErrors {
+		assert.Equal(t, runner.SeverityWarning, lintErr.Severity)
+		assert.Equal(t, "default", lintErr.Job)
+		assert.Equal(t, "unreachable step", lintErr.Issue)
+	}
+	assert.Contains(t, lintErrors[0].Detail, `step 0 condition "false"`)
+	assert.Contains(t, lintErrors[1].Detail, `step 1 condition "1 == 2"`)
+}
+
+func TestLinter_ShadowedVars(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: shadowing
+vars:
+  version: 1.0.0
+  target: linux
+jobs:
+  default:
+    vars:
+      target: darwin
+    steps:
+      - run: echo ${{ target }}
+        vars:
+          target: windows
+          version: 2.0.0
+      - run: echo ${{ arch }}
+        vars:
+          arch: amd64
+`)
+
+	lintErrors := runner.NewLinter(pipeline).Lint()
+	require.Len(t, lintErrors, 3)
+
+	for _, lintErr := range lintErrors {
+		assert.Equal(t, runner.SeverityWarning, lintErr.Severity)
+		assert.Equal(t, "default", lintErr.Job)
+		assert.Equal(t, "shadowed variable", lintErr.Issue)
+	}
+	assert.Equal(t, `job var "target" shadows pipeline var`, lintErrors[0].Detail)
+	assert.Equal(t, `step 0 var "target" shadows job var`, lintErrors[1].Detail)
+	assert.Equal(t, `step 0 var "version" shadows pipeline var`, lintErrors[2].Detail)
+
+	linter := runner.NewLinter(pipeline)
+	linter.Ignore("shadowed-variable")
+	assert.Empty(t, linter.Lint())
+}
+
+func TestLinter_NoShadowedVars(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: distinct
+vars:
+  version: 1.0.0
+jobs:
+  default:
+    vars:
+      target: darwin
+    steps:
+      - run: echo ${{ arch }}
+        vars:
+          arch: amd64
+`)
+
+	assert.Empty(t, runner.NewLinter(pipeline).Lint())
+}
+
+func TestLinter_MissingSnippet(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: snippets
+snippets:
+  build: go build ./...
+jobs:
+  default:
+    steps:
+      - use_snippet: build
+      - use_snippet: test
+`)
+
+	lintErrors := runner.NewLinter(pipeline).Lint()
+	require.Len(t, lintErrors, 1)
+	assert.Equal(t, "missing snippet reference", lintErrors[0].Issue)
+	assert.Equal(t, "step uses snippet 'test', but snippet not found", lintErrors[0].Detail)
+}
+
+func TestLinter_FailThreshold(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: threshold
+jobs:
+  default:
+    steps:
+      - run: exit ${{ code }}
+        for: code in [0, 1]
+        fail_threshold: 10%
+      - run: exit ${{ code }}
+        for: code in [0, 1]
+        fail_threshold: lots
+      - run: "true"
+        fail_threshold: 1
+`)
+
+	lintErrors := runner.NewLinter(pipeline).Lint()
+	require.Len(t, lintErrors, 2)
+	assert.Equal(t, "invalid fail_threshold", lintErrors[0].Issue)
+	assert.Equal(t, `step 1: invalid fail_threshold "lots": expected a count or a percentage`, lintErrors[0].Detail)
+	assert.False(t, lintErrors[0].IsWarning())
+	assert.Equal(t, "unused fail_threshold", lintErrors[1].Issue)
+	assert.Equal(t, "step 2 sets fail_threshold without for, it has no effect", lintErrors[1].Detail)
+	assert.True(t, lintErrors[1].IsWarning())
+}
+
+func TestLinter_TaskRecursion(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: recursion
+jobs:
+  default:
+    steps:
+      - task: a
+  a:
+    steps:
+      - task: b
+  b:
+    steps:
+      - task: a
+  self:
+    steps:
+      - task: self
+  guarded:
+    steps:
+      - task: guarded
+        if: done == false
+`)
+
+	lintErrors := runner.NewLinter(pipeline).Lint()
+	require.Len(t, lintErrors, 2)
+	for _, lintErr := range lintErrors {
+		assert.Equal(t, runner.SeverityError, lintErr.Severity)
+		assert.Equal(t, "task recursion", lintErr.Issue)
+	}
+	assert.Equal(t, "a", lintErrors[0].Job)
+	assert.Equal(t, "task invokes itself without an if condition: a -> b -> a", lintErrors[0].Detail)
+	assert.Equal(t, "self", lintErrors[1].Job)
+	assert.Equal(t, "task invokes itself without an if condition: self -> self", lintErrors[1].Detail)
+}
@@ -1,23 +1,124 @@
 package runner
 
 import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
 	"github.com/titpetric/atkins/model"
 	"github.com/titpetric/atkins/treeview"
 )
 
+// ListOptions contains options for listing a pipeline.
+type ListOptions struct {
+	TreeStyle string // Tree drawing style: unicode, ascii or empty to auto-detect
+	Format    string // Output format: tree (default), dot or json
+	Kinds     bool   // Label jobs as [job], [task] or [nested]
+	Commands  bool   // Show the full command text for every step
+	ShowIDs   bool   // Show node IDs as used in the event log
+	DescLines bool   // Show job descriptions on a line below the job
+	Verbose   bool   // Show step descriptions on a line below each step
+	Flat      bool   // Print only the root job names, one per line
+}
+
 // ListPipeline displays a pipeline's job tree with dependencies.
-func ListPipeline(pipeline *model.Pipeline) error {
-	allJobs := pipeline.Jobs
-	if len(allJobs) == 0 {
-		allJobs = pipeline.Tasks
+func ListPipeline(pipeline *model.Pipeline, opts ListOptions) error {
+	if opts.Flat {
+		for _, name := range RootJobNames(pipeline) {
+			fmt.Println(name)
+		}
+		return nil
 	}
 
-	node, err := treeview.BuildFromPipeline(pipeline, ResolveJobDependencies)
+	switch opts.Format {
+	case "", "tree":
+	case "dot":
+		return BuildGraph(pipeline).WriteDOT(os.Stdout)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ListJobs(pipeline))
+	default:
+		return fmt.Errorf("unknown list format %q (expected tree, dot or json)", opts.Format)
+	}
+
+	style, err := treeview.ParseTreeStyle(opts.TreeStyle)
+	if err != nil {
+		return err
+	}
+
+	node, err := treeview.BuildFromPipelineWithOptions(pipeline, ResolveJobDependencies, treeview.BuildOptions{
+		Kinds:     opts.Kinds,
+		Commands:  opts.Commands,
+		DescLines: opts.DescLines,
+		Verbose:   opts.Verbose,
+	})
 	if err != nil {
 		return err
 	}
 
 	display := treeview.NewDisplay()
+	display.SetTreeStyle(style)
+	display.SetShowIDs(opts.ShowIDs)
 	display.RenderStatic(node)
 	return nil
 }
+
+// PipelineJobs returns the jobs of pipeline, or its tasks if it has no jobs.
+func PipelineJobs(pipeline *model.Pipeline) map[string]*model.Job {
+	if len(pipeline.Jobs) == 0 {
+		return pipeline.Tasks
+	}
+	return pipeline.Jobs
+}
+
+// RootJobNames returns the sorted names of the root jobs offered to run,
+// leaving out nested jobs like `test:unit` and jobs hidden with show: false.
+func RootJobNames(pipeline *model.Pipeline) []string {
+	jobs := PipelineJobs(pipeline)
+
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(jobs)) {
+		if treeview.JobKind(name, jobs[name]) == treeview.JobKindJob {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// JobInfo describes a job for machine readable listings.
+type JobInfo struct {
+	Name      string   `json:"name"`
+	Desc      string   `json:"desc,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	Kind      string   `json:"kind"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Requires  []string `json:"requires,omitempty"`
+	Timeout   string   `json:"timeout,omitempty"`
+	Detach    bool     `json:"detach,omitempty"`
+	Steps     int      `json:"steps"`
+}
+
+// ListJobs returns job information for all jobs in the pipeline, sorted by name.
+func ListJobs(pipeline *model.Pipeline) []JobInfo {
+	jobs := PipelineJobs(pipeline)
+
+	result := make([]JobInfo, 0, len(jobs))
+	for _, name := range slices.Sorted(maps.Keys(jobs)) {
+		job := jobs[name]
+		result = append(result, JobInfo{
+			Name:      name,
+			Desc:      job.Desc,
+			Group:     job.Group,
+			Kind:      treeview.JobKind(name, job),
+			DependsOn: GetDependencies(job.DependsOn),
+			Requires:  job.Requires,
+			Timeout:   job.Timeout,
+			Detach:    job.Detach.IsSet(),
+			Steps:     len(job.Children()),
+		})
+	}
+	return result
+}
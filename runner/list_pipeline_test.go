@@ -0,0 +1,94 @@
+package runner_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestListJobs_JSON(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: listing
+jobs:
+  build:
+    desc: Build the binary
+    group: ci
+    timeout: 10m
+    detach: true
+    requires: [target]
+    depends_on: setup
+    steps:
+      - go build ./...
+      - go vet ./...
+  setup:
+    steps:
+      - go mod download
+`)
+
+	data, err := json.Marshal(runner.ListJobs(pipeline))
+	require.NoError(t, err)
+
+	var jobs []map[string]any
+	require.NoError(t, json.Unmarshal(data, &jobs))
+	require.Len(t, jobs, 2)
+
+	assert.Equal(t, map[string]any{
+		"name":       "build",
+		"desc":       "Build the binary",
+		"group":      "ci",
+		"kind":       "job",
+		"depends_on": []any{"setup"},
+		"requires":   []any{"target"},
+		"timeout":    "10m",
+		"detach":     true,
+		"steps":      float64(2),
+	}, jobs[0])
+
+	// Empty fields are omitted
+	assert.Equal(t, map[string]any{
+		"name":  "setup",
+		"kind":  "job",
+		"steps": float64(1),
+	}, jobs[1])
+}
+
+func TestListPipeline_Flat(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: listing
+jobs:
+  test:
+    steps:
+      - go test ./...
+  build:
+    steps:
+      - go build ./...
+  test:unit:
+    steps:
+      - go test -short ./...
+  release:
+    show: false
+    steps:
+      - goreleaser
+`)
+
+	stdout := filepath.Join(t.TempDir(), "stdout")
+	f, err := os.Create(stdout)
+	require.NoError(t, err)
+
+	orig := os.Stdout
+	os.Stdout = f
+	err = runner.ListPipeline(pipeline, runner.ListOptions{Flat: true})
+	os.Stdout = orig
+	require.NoError(t, f.Close())
+	require.NoError(t, err)
+
+	out, err := os.ReadFile(stdout)
+	require.NoError(t, err)
+	assert.Equal(t, "build\ntest\n", string(out))
+}
@@ -1,9 +1,14 @@
 package runner
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	yaml "gopkg.in/yaml.v3"
@@ -12,7 +17,7 @@ import (
 )
 
 // LoadPipeline loads and parses a pipeline from a yaml file.
-// Returns the number of documents loaded, the parsed pipeline, and any error.
+// Includes are resolved relative to the directory of the file.
 func LoadPipeline(filePath string) ([]*model.Pipeline, error) {
 	// Read the raw file content
 	data, err := os.ReadFile(filePath)
@@ -20,8 +25,29 @@ func LoadPipeline(filePath string) ([]*model.Pipeline, error) {
 		return nil, fmt.Errorf("failed to read pipeline file: %w", err)
 	}
 
+	result, err := LoadPipelineReader(bytes.NewReader(data), filepath.Dir(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set default name from filename if not specified
+	if result[0].Name == "" {
+		result[0].Name = filepath.Base(filePath)
+	}
+
+	return result, nil
+}
+
+// LoadPipelineReader loads and parses a pipeline from r. Relative
+// include paths are resolved against baseDir, if set.
+func LoadPipelineReader(r io.Reader, baseDir string) ([]*model.Pipeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline: %w", err)
+	}
+
 	// Parse with plain YAML first (no expression evaluation)
-	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
 
 	result := []*model.Pipeline{
 		{},
@@ -29,11 +55,8 @@ func LoadPipeline(filePath string) ([]*model.Pipeline, error) {
 	if err := decoder.Decode(result[0]); err != nil {
 		return nil, fmt.Errorf("error decoding pipeline: %w", err)
 	}
-
-	// Set default name from filename if not specified
-	if result[0].Name == "" {
-		result[0].Name = filepath.Base(filePath)
-	}
+	result[0].Fingerprint = fingerprint(data)
+	result[0].Dir = baseDir
 
 	for jobName, job := range result[0].Jobs {
 		job.Name = jobName
@@ -49,5 +72,82 @@ func LoadPipeline(filePath string) ([]*model.Pipeline, error) {
 		}
 	}
 
+	resolveDependsOn(result[0], result[0].Jobs)
+	resolveDependsOn(result[0], result[0].Tasks)
+
+	if baseDir != "" {
+		resolveIncludes(result[0], baseDir)
+	}
+
 	return result, nil
 }
+
+// resolveIncludes makes the relative include paths of the pipeline,
+// its jobs and steps relative to baseDir. Paths starting with a
+// variable are left as they are, as they may expand to absolute paths.
+func resolveIncludes(pipeline *model.Pipeline, baseDir string) {
+	resolve := func(decl *model.Decl) {
+		if decl == nil {
+			return
+		}
+		includes := []*model.IncludeDecl{decl.Include}
+		if decl.Env != nil {
+			includes = append(includes, decl.Env.Include)
+		}
+		for _, include := range includes {
+			if include == nil {
+				continue
+			}
+			for i, file := range include.Files {
+				if !filepath.IsAbs(file) && !strings.HasPrefix(file, "$") {
+					include.Files[i] = filepath.Join(baseDir, file)
+				}
+			}
+		}
+	}
+
+	resolve(pipeline.Decl)
+	for _, jobs := range []map[string]*model.Job{pipeline.Jobs, pipeline.Tasks} {
+		for _, job := range jobs {
+			resolve(job.Decl)
+			for _, step := range job.Children() {
+				resolve(step.Decl)
+			}
+		}
+	}
+}
+
+// fingerprint returns the hex encoded SHA-256 of the pipeline file contents.
+func fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveDependsOn interpolates ${{ ... }} in depends_on entries with the
+// pipeline and job vars, so a job can depend on its matching counterpart,
+// e.g. `build-linux` with `os: linux` on `test-${{ os }}`. Command
+// substitutions in vars are not executed.
+func resolveDependsOn(pipeline *model.Pipeline, jobs map[string]*model.Job) {
+	var pipelineCtx *ExecutionContext
+	for _, job := range jobs {
+		if !slices.ContainsFunc(job.DependsOn, func(dep string) bool {
+			return interpolationRegex.MatchString(dep)
+		}) {
+			continue
+		}
+
+		if pipelineCtx == nil {
+			pipelineCtx = &ExecutionContext{
+				Variables: make(map[string]any),
+				Env:       make(map[string]string),
+			}
+			planDecl(pipelineCtx, pipeline.Decl)
+		}
+
+		jobCtx := pipelineCtx.Copy()
+		planDecl(jobCtx, job.Decl)
+		for i, dep := range job.DependsOn {
+			job.DependsOn[i], _ = interpolateVariablesInString(dep, jobCtx)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package runner_test
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -163,6 +165,40 @@ func TestEvaluateIfInContext(t *testing.T) {
 	}
 }
 
+// TestEvaluateIf_NumericStrings tests comparing numeric strings, e.g. from $(...), as numbers
+func TestEvaluateIf_NumericStrings(t *testing.T) {
+	tests := []struct {
+		ifCond   string
+		vars     map[string]any
+		wantBool bool
+	}{
+		{"count > 3", map[string]any{"count": "5"}, true},
+		{"count > 3", map[string]any{"count": "2"}, false},
+		{"count >= 2.5", map[string]any{"count": "2.5"}, true},
+		{"count == 5", map[string]any{"count": "5"}, true},
+		{"count != 5", map[string]any{"count": "5"}, false},
+		{"count - 1 == 4", map[string]any{"count": "5"}, true},
+		{"a < b", map[string]any{"a": "9", "b": "10"}, true},
+		{"a < b", map[string]any{"a": "abc", "b": "abd"}, true},
+		{"version == '1.0'", map[string]any{"version": "1.0"}, true},
+		{"name + '1' == 'v1'", map[string]any{"name": "v"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ifCond, func(t *testing.T) {
+			ctx := &runner.ExecutionContext{
+				Variables: tt.vars,
+				Env:       map[string]string{},
+				Step:      &model.Step{If: tt.ifCond},
+			}
+
+			result, err := runner.EvaluateIf(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBool, result)
+		})
+	}
+}
+
 // TestExpandForWithVariables tests expanding for loops with context variables
 func TestExpandForWithVariables(t *testing.T) {
 	tests := []struct {
@@ -343,3 +379,166 @@ jobs:
 	assert.NotNil(t, ctx.Variables["testBinaries"], "testBinaries should be in context after MergeVariables")
 	assert.Equal(t, "file1.test\nfile2.test", ctx.Variables["testBinaries"])
 }
+
+// TestLoadPipeline_DependsOnInterpolation tests linking jobs to their matching counterpart
+func TestLoadPipeline_DependsOnInterpolation(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: linked
+vars:
+  suffix: ci
+jobs:
+  build-linux:
+    vars:
+      os: linux
+    depends_on: test-${{ os }}
+  build-darwin:
+    vars:
+      os: darwin
+    depends_on: ["test-${{ os }}", "lint-${{ suffix }}"]
+  test-linux: {}
+  test-darwin: {}
+  lint-ci: {}
+`)
+
+	assert.Equal(t, model.Dependencies{"test-linux"}, pipeline.Jobs["build-linux"].DependsOn)
+	assert.Equal(t, model.Dependencies{"test-darwin", "lint-ci"}, pipeline.Jobs["build-darwin"].DependsOn)
+
+	graph := runner.BuildGraph(pipeline)
+	assert.True(t, hasEdge(graph, "job:test-linux", "job:build-linux"))
+	assert.True(t, hasEdge(graph, "job:test-darwin", "job:build-darwin"))
+	assert.False(t, hasEdge(graph, "job:test-darwin", "job:build-linux"))
+	assert.False(t, hasEdge(graph, "job:test-linux", "job:build-darwin"))
+}
+
+func TestLoadPipeline_Fingerprint(t *testing.T) {
+	content := `
+name: fingerprint
+jobs:
+  default:
+    steps:
+      - run: echo hello
+`
+	first := loadTestPipeline(t, content)
+	second := loadTestPipeline(t, content)
+	changed := loadTestPipeline(t, content+"      - run: echo world\n")
+
+	assert.Len(t, first.Fingerprint, 64)
+	assert.Equal(t, first.Fingerprint, second.Fingerprint)
+	assert.NotEqual(t, first.Fingerprint, changed.Fingerprint)
+}
+
+func TestLoadPipelineReader_Includes(t *testing.T) {
+	baseDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, "vars.yml"), []byte("greeting: hello\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, "job.yml"), []byte("target: world\n"), 0o644))
+
+	pipelines, err := runner.LoadPipelineReader(strings.NewReader(`
+name: reader
+include: vars.yml
+env:
+  include: .env
+jobs:
+  default:
+    include: job.yml
+    steps:
+      - run: echo hello
+`), baseDir)
+	assert.NoError(t, err)
+	assert.Len(t, pipelines, 1)
+
+	pipeline := pipelines[0]
+	assert.Equal(t, "reader", pipeline.Name)
+	assert.Len(t, pipeline.Fingerprint, 64)
+	assert.Equal(t, []string{filepath.Join(baseDir, ".env")}, pipeline.Env.Include.Files)
+
+	ctx := &runner.ExecutionContext{Variables: map[string]any{}, Env: map[string]string{}}
+	vars, err := runner.ProcessDecl(pipeline.Decl, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", vars["greeting"])
+
+	vars, err = runner.ProcessDecl(pipeline.Jobs["default"].Decl, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", vars["target"])
+}
+
+func TestLoadPipelineReader_NoBaseDir(t *testing.T) {
+	pipelines, err := runner.LoadPipelineReader(strings.NewReader(`
+include: vars.yml
+jobs:
+  default:
+    steps:
+      - run: echo hello
+`), "")
+	assert.NoError(t, err)
+	assert.Empty(t, pipelines[0].Name)
+	assert.Equal(t, []string{"vars.yml"}, pipelines[0].Include.Files)
+}
+
+func TestLoadPipelineReader_InterpolatedIncludes(t *testing.T) {
+	pipelines, err := runner.LoadPipelineReader(strings.NewReader(`
+include:
+  - config/${{ stage }}.yml
+  - $HOME/vars.yml
+jobs:
+  default:
+    steps:
+      - run: echo hello
+`), "/base")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("/base", "config/${{ stage }}.yml"), "$HOME/vars.yml"}, pipelines[0].Include.Files)
+}
+
+func TestLoadPipelineReader_MergeKeys(t *testing.T) {
+	pipelines, err := runner.LoadPipelineReader(strings.NewReader(`
+name: anchors
+x-job: &job
+  timeout: 1m
+  env:
+    vars:
+      SHARED: shared
+  vars:
+    greeting: hello
+x-region: &region
+  region: eu
+x-tier: &tier
+  tier: web
+x-step: &step
+  if: success()
+  vars:
+    level: step
+jobs:
+  build:
+    <<: *job
+    steps:
+      - <<: *step
+        run: echo build
+  deploy:
+    <<: *job
+    vars:
+      <<: [*region, *tier]
+      greeting: hi
+    steps:
+      - <<: *step
+        vars:
+          level: override
+        run: echo deploy
+`), "")
+	assert.NoError(t, err)
+	assert.Len(t, pipelines, 1)
+
+	build := pipelines[0].Jobs["build"]
+	assert.Equal(t, "1m", build.Timeout)
+	assert.Equal(t, map[string]any{"greeting": "hello"}, build.Vars)
+	assert.Equal(t, map[string]any{"SHARED": "shared"}, build.Env.Vars)
+	assert.Equal(t, "success()", build.Steps[0].If)
+	assert.Equal(t, map[string]any{"level": "step"}, build.Steps[0].Vars)
+
+	// Keys next to a merge key override the merged ones
+	deploy := pipelines[0].Jobs["deploy"]
+	assert.Equal(t, "1m", deploy.Timeout)
+	assert.Equal(t, map[string]any{"region": "eu", "tier": "web", "greeting": "hi"}, deploy.Vars)
+	assert.Equal(t, map[string]any{"SHARED": "shared"}, deploy.Env.Vars)
+	assert.Equal(t, "success()", deploy.Steps[0].If)
+	assert.Equal(t, map[string]any{"level": "override"}, deploy.Steps[0].Vars)
+	assert.Equal(t, "echo deploy", deploy.Steps[0].Run)
+}
@@ -1,6 +1,9 @@
 package runner
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 // generateStepID creates a step ID from job name and sequential step index
 // Format follows GitHub Actions: jobs.<jobName>.steps.<sequentialIndex>
@@ -11,3 +14,15 @@ func generateStepID(jobName string, stepIndex int) string {
 	// Format: jobs.<jobName>.steps.<sequentialIndex>
 	return "jobs." + jobName + ".steps." + fmt.Sprintf("%d", stepIndex)
 }
+
+// iterationSuffix creates the step ID suffix for a for loop iteration,
+// e.g. jobs.<jobName>.steps.<sequentialIndex>.iter.<iteration>
+func iterationSuffix(iteration int) string {
+	return ".iter." + strconv.Itoa(iteration)
+}
+
+// parallelSuffix creates the step ID suffix for a step of a parallel block,
+// e.g. jobs.<jobName>.steps.<sequentialIndex>.parallel.<index>
+func parallelSuffix(index int) string {
+	return ".parallel." + strconv.Itoa(index)
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/titpetric/atkins/eventlog"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// LoadLogTree reads an event log written with --log and returns the run
+// tree it recorded, with the results of the logged events applied.
+func LoadLogTree(filePath string) (*treeview.Node, error) {
+	log, err := eventlog.ReadLog(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if log.State == nil {
+		return treeview.NewNode(log.Metadata.Pipeline), nil
+	}
+
+	byID := make(map[string]*eventlog.Event, len(log.Events))
+	for _, event := range log.Events {
+		byID[event.ID] = event
+	}
+
+	root := eventlog.StateNodeToNode(log.State)
+	applyEventResults(root, byID)
+	return root, nil
+}
+
+// applyEventResults marks nodes with a failed event as failed. The tree
+// may not be updated yet when a run fails, the event result is final.
+func applyEventResults(node *treeview.Node, events map[string]*eventlog.Event) {
+	if event := events[node.ID]; event != nil && event.Result == eventlog.ResultFail {
+		node.Status = treeview.StatusFailed
+	}
+	for _, child := range node.Children {
+		applyEventResults(child, events)
+	}
+}
+
+// DumpLogTree renders the run tree of an event log as after a run.
+// Only the TreeStyle and ShowIDs options apply.
+func DumpLogTree(filePath string, opts ListOptions) error {
+	style, err := treeview.ParseTreeStyle(opts.TreeStyle)
+	if err != nil {
+		return err
+	}
+
+	root, err := LoadLogTree(filePath)
+	if err != nil {
+		return err
+	}
+
+	// Steps show their status too, as in the tree of a run
+	renderer := treeview.NewRenderer()
+	renderer.SetTreeStyle(style)
+	renderer.SetShowIDs(opts.ShowIDs)
+	fmt.Print(renderer.Render(root))
+	return nil
+}
@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// stepSignal is closed when a step with an id has finished.
+type stepSignal struct {
+	done   chan struct{}
+	once   sync.Once
+	failed atomic.Bool
+}
+
+// stepSignals tracks the completion of steps with an id, for steps that need them.
+type stepSignals map[string]*stepSignal
+
+// newStepSignals validates step needs and creates a signal for every step id.
+// A step can only need earlier steps that aren't deferred.
+func newStepSignals(steps []*model.Step) (stepSignals, error) {
+	signals := make(stepSignals)
+	for idx, step := range steps {
+		for _, need := range step.Needs {
+			if _, ok := signals[need]; !ok {
+				return nil, fmt.Errorf("step %d needs %q, which is not an earlier step id", idx, need)
+			}
+		}
+		if step.ID == "" || step.IsDeferred() {
+			continue
+		}
+		if _, ok := signals[step.ID]; ok {
+			return nil, fmt.Errorf("step %d has duplicate id %q", idx, step.ID)
+		}
+		signals[step.ID] = &stepSignal{done: make(chan struct{})}
+	}
+	return signals, nil
+}
+
+// finish marks the step as done. Steps that need a failed step are skipped.
+func (s stepSignals) finish(step *model.Step, failed bool) {
+	signal, ok := s[step.ID]
+	if !ok {
+		return
+	}
+	signal.once.Do(func() {
+		signal.failed.Store(failed)
+		close(signal.done)
+	})
+}
+
+// wait blocks until the steps needed by step have finished. It returns
+// false if any of them failed or the context was cancelled.
+func (s stepSignals) wait(ctx context.Context, step *model.Step) bool {
+	for _, need := range step.Needs {
+		signal := s[need]
+		select {
+		case <-signal.done:
+			if signal.failed.Load() {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// stepResults holds the outcome and exit code of finished steps with an
+// id, available to later steps as steps.<id>.
+type stepResults struct {
+	mu      sync.Mutex
+	results map[string]any
+}
+
+func newStepResults() *stepResults {
+	return &stepResults{results: make(map[string]any)}
+}
+
+// record stores the result of step, the exit code of the last failed
+// command or 1 if the step failed otherwise.
+func (r *stepResults) record(step *model.Step, err error) {
+	if r == nil || step.ID == "" {
+		return
+	}
+
+	outcome, exitCode := "success", 0
+	if err != nil {
+		outcome, exitCode = "failure", 1
+		var execErr ExecError
+		if errors.As(err, &execErr) && execErr.LastExitCode != 0 {
+			exitCode = execErr.LastExitCode
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results[step.ID] = map[string]any{
+		"outcome":   outcome,
+		"exit_code": exitCode,
+	}
+}
+
+// apply sets steps in vars to a copy of the recorded results.
+func (r *stepResults) apply(vars map[string]any) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.results) > 0 {
+		vars["steps"] = maps.Clone(r.results)
+	}
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+)
+
+// numericOptions returns opts with options to compare and compute with
+// numeric strings as numbers, e.g. `count > 3` where count is the output
+// of $(...).
+func numericOptions(opts ...expr.Option) []expr.Option {
+	return append([]expr.Option{
+		expr.Function("toNumber", func(params ...any) (any, error) {
+			return toNumber(params[0]), nil
+		}),
+		expr.Patch(numericPatch{}),
+	}, opts...)
+}
+
+var numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// toNumber converts a string holding an integer or decimal number to int
+// or float64. Other values are returned unchanged.
+func toNumber(v any) any {
+	s, ok := v.(string)
+	if !ok || !numberPattern.MatchString(s) {
+		return v
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return v
+}
+
+// numericPatch wraps operands of numeric operators with toNumber.
+// Ordering and arithmetic operators convert both operands. Equality and
+// `+` convert an operand only when the other is a number literal, so
+// string comparisons and concatenation keep working.
+type numericPatch struct{}
+
+// Visit implements ast.Visitor.
+func (numericPatch) Visit(node *ast.Node) {
+	n, ok := (*node).(*ast.BinaryNode)
+	if !ok {
+		return
+	}
+
+	switch n.Operator {
+	case "<", ">", "<=", ">=", "-", "*", "/", "%", "**", "^":
+		wrapNumber(&n.Left)
+		wrapNumber(&n.Right)
+	case "==", "!=", "+":
+		if isNumberLiteral(n.Right) {
+			wrapNumber(&n.Left)
+		}
+		if isNumberLiteral(n.Left) {
+			wrapNumber(&n.Right)
+		}
+	}
+}
+
+// wrapNumber replaces node with a toNumber call on it.
+func wrapNumber(node *ast.Node) {
+	if isNumberLiteral(*node) {
+		return
+	}
+	ast.Patch(node, &ast.CallNode{
+		Callee:    &ast.IdentifierNode{Value: "toNumber"},
+		Arguments: []ast.Node{*node},
+	})
+}
+
+func isNumberLiteral(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.IntegerNode, *ast.FloatNode:
+		return true
+	}
+	return false
+}
@@ -2,20 +2,26 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/titpetric/atkins/colors"
 	"github.com/titpetric/atkins/eventlog"
 	"github.com/titpetric/atkins/model"
 	"github.com/titpetric/atkins/treeview"
 )
 
+// errMaxFailures cancels the jobs once PipelineOptions.MaxFailures is reached.
+var errMaxFailures = errors.New("max failures reached")
+
 // PipelineOptions contains options for running a pipeline.
 type PipelineOptions struct {
 	Job          string
@@ -23,6 +29,116 @@ type PipelineOptions struct {
 	PipelineFile string
 	Debug        bool
 	FinalOnly    bool
+	TreeStyle    string // Tree drawing style: unicode, ascii or empty to auto-detect
+	EventStream  string // File receiving events as NDJSON while the pipeline runs
+	OnlyChanged  bool   // Skip jobs and steps whose run_if_changed files didn't change since the last success
+	ShowIDs      bool   // Show node IDs as used in the event log
+	FailOnEmpty  bool   // Fail jobs where every step was skipped or none are defined
+	Summary      bool   // Print a one-line summary with step counts and duration to stderr
+	Profile      string // Also load <file>.<profile> after each env include, e.g. .env.prod
+
+	// EnvPassthrough restricts the OS environment visible to commands
+	// to these variables, in addition to the pipeline env_passthrough.
+	EnvPassthrough []string
+
+	// GitHubAnnotations prints job output groups and error annotations
+	// as GitHub Actions workflow commands after the run.
+	GitHubAnnotations bool
+
+	// ShowAllOutput shows the passthru output of passed steps,
+	// which is folded by default.
+	ShowAllOutput bool
+
+	// Args are positional CLI arguments after the job name,
+	// available to the pipeline as ${{ args[0] }}, ...
+	Args []string
+
+	// OnComplete is called with the job count and summary after the run.
+	OnComplete func(jobs int, summary *eventlog.RunSummary)
+
+	// Steps selects the steps of Job (or the default job) to run,
+	// by zero based index. The other steps are skipped.
+	Steps StepSelection
+
+	// Collapse renders runs of passed or skipped steps without
+	// visible output as a single line, e.g. `✓ x47`.
+	Collapse bool
+
+	// OnFail is a job run once when the pipeline fails, with the
+	// failed job as ${{ failed_job }}. It overrides on_failure_job.
+	OnFail string
+
+	// MaxDepth limits how deep tasks may invoke other tasks,
+	// DefaultMaxTaskDepth if zero.
+	MaxDepth int
+
+	// StrictEnv fails the run if an env include file is readable by
+	// group or others. By default a warning is printed.
+	StrictEnv bool
+
+	// BaseRef is the git ref that run_if_changed globs are compared
+	// against with OnlyChanged. If empty, the first of DefaultBaseRefs
+	// that exists is used. Without a git ref, changes are detected from
+	// modification times since the last success.
+	BaseRef string
+
+	// MaxLogAge prunes files in DefaultStateDir older than this
+	// when the run starts. Zero keeps all files. Like the change
+	// state file, the directory is relative to the working directory,
+	// which is the pipeline directory when the pipeline is discovered.
+	MaxLogAge time.Duration
+
+	// SubstitutionTimeout limits how long a $(...) command
+	// substitution may run, DefaultSubstitutionTimeout if zero.
+	SubstitutionTimeout time.Duration
+
+	// DescLines renders job descriptions on a dimmed line below
+	// the job, instead of appending them to the job name.
+	DescLines bool
+
+	// JSONEvents streams events as JSON lines to stdout and
+	// doesn't render the tree.
+	JSONEvents bool
+
+	// ReportHTML is a file receiving the run tree as an HTML page
+	// when the pipeline finishes.
+	ReportHTML string
+
+	// LogOnFailure only writes LogFile when the run failed.
+	LogOnFailure bool
+
+	// NoDeps runs only Job (or the default job), its dependencies
+	// are assumed to have completed.
+	NoDeps bool
+
+	// JobTimeout bounds jobs without their own timeout,
+	// overriding the executor default of 5 minutes.
+	JobTimeout time.Duration
+
+	// Redactor scrubs its patterns from captured output, labels
+	// and the event log, nil to keep them as is.
+	Redactor *Redactor
+
+	// MaxParallel limits how many detached jobs run at once,
+	// zero is unlimited. Jobs with a higher priority start first.
+	MaxParallel int
+
+	// Spinner animates the status of running nodes: none (default),
+	// dots, line or arrows. SpinnerInterval overrides its frame interval.
+	Spinner         string
+	SpinnerInterval time.Duration
+
+	// OnlyFailedOutput prints the captured output of all failed
+	// steps in a Failures section when the run failed.
+	OnlyFailedOutput bool
+
+	// MaxFailures cancels the run once that many detached jobs
+	// failed, jobs that didn't start are skipped. Zero is unlimited.
+	MaxFailures int
+
+	// ExplainSkips prints every skipped step with the reason it
+	// was skipped when the run finishes.
+	ExplainSkips bool
 }
 
 // Pipeline holds pipeline execution logic.
@@ -44,8 +160,35 @@ func RunPipeline(ctx context.Context, pipeline *model.Pipeline, opts PipelineOpt
 	var logger *eventlog.Logger
 	if opts.LogFile != "" || opts.PipelineFile != "" {
 		logger = eventlog.NewLogger(opts.LogFile, pipeline.Name, opts.PipelineFile, opts.Debug)
+		logger.SetWriteOnFailure(opts.LogOnFailure)
+	}
+
+	var streams []io.Writer
+	if opts.EventStream != "" {
+		stream, err := os.Create(opts.EventStream)
+		if err != nil {
+			return fmt.Errorf("failed to open event stream: %w", err)
+		}
+		defer stream.Close()
+		streams = append(streams, stream)
+	}
+	if opts.JSONEvents {
+		streams = append(streams, os.Stdout)
+	}
+	if len(streams) > 0 {
+		stream := io.MultiWriter(streams...)
+		if logger == nil {
+			logger = eventlog.NewStreamLogger(stream, pipeline.Name, opts.PipelineFile, opts.Debug)
+		} else {
+			logger.SetStream(stream)
+		}
 	}
 
+	if (opts.GitHubAnnotations || opts.ReportHTML != "" || opts.OnlyFailedOutput) && logger == nil {
+		logger = eventlog.NewMemoryLogger(pipeline.Name, opts.PipelineFile, opts.Debug)
+	}
+	logger.SetFingerprint(pipeline.Fingerprint)
+
 	service := NewPipeline(pipeline, opts)
 
 	return service.runPipeline(ctx, logger)
@@ -58,33 +201,76 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		finalOnly = p.opts.FinalOnly
 	)
 
+	style, err := treeview.ParseTreeStyle(p.opts.TreeStyle)
+	if err != nil {
+		return err
+	}
+
+	spinner, err := treeview.ParseSpinner(p.opts.Spinner)
+	if err != nil {
+		return err
+	}
+
+	if p.opts.Redactor != nil {
+		logger.SetRedact(p.opts.Redactor.Redact)
+	}
+
+	start := time.Now()
 	tree := treeview.NewBuilder(pipeline.Name)
 	root := tree.Root()
 
 	display := treeview.NewDisplayWithFinal(finalOnly)
-	pipelineCtx := &ExecutionContext{
-		Variables:    make(map[string]any),
-		Env:          make(map[string]string),
-		Results:      make(map[string]any),
-		Pipeline:     pipeline,
-		Depth:        0,
-		Builder:      tree,
-		Display:      display,
-		Context:      ctx,
-		JobNodes:     make(map[string]*treeview.TreeNode),
-		EventLogger:  logger,
-		JobCompleted: make(map[string]bool),
-	}
-
-	// Copy environment variables from OS
-	for _, env := range os.Environ() {
-		k, v := parseEnv(env)
-		if k != "" {
-			pipelineCtx.Env[k] = v
+	display.SetTreeStyle(style)
+	display.SetShowIDs(p.opts.ShowIDs)
+	display.SetShowAllOutput(p.opts.ShowAllOutput)
+	display.SetCollapse(p.opts.Collapse)
+	display.SetHidden(p.opts.JSONEvents)
+	display.SetSpinner(spinner, p.opts.SpinnerInterval)
+	if p.opts.Redactor != nil {
+		display.SetRedact(p.opts.Redactor.Redact)
+	}
+	defer display.Stop()
+
+	if p.opts.MaxLogAge > 0 {
+		removed, err := PruneStateDir(DefaultStateDir, time.Now().Add(-p.opts.MaxLogAge))
+		if p.opts.Debug {
+			for _, path := range removed {
+				fmt.Fprintf(os.Stderr, "pruned %s\n", path)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed to prune %s: %s\n", colors.BrightYellow("!"), DefaultStateDir, err)
 		}
 	}
 
-	if err := MergeVariables(pipeline.Decl, pipelineCtx); err != nil {
+	var changeState *ChangeState
+	if p.opts.OnlyChanged {
+		state, err := LoadChangeState(DefaultChangeStateFile)
+		if err != nil {
+			return err
+		}
+		baseRef, err := ResolveBaseRef(p.opts.BaseRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s, comparing against the last successful run\n", colors.BrightYellow("!"), err)
+		}
+		state.SetBaseRef(baseRef)
+		changeState = state
+	}
+
+	if err := ValidatePipelineTools(pipeline); err != nil {
+		return err
+	}
+
+	pipelineCtx, err := newPipelineContext(ctx, pipeline, p.opts)
+	if err != nil {
+		return err
+	}
+	pipelineCtx.Builder = tree
+	pipelineCtx.Display = display
+	pipelineCtx.EventLogger = logger
+	pipelineCtx.ChangeState = changeState
+
+	if err := ValidatePipelineRequirements(pipeline, pipelineCtx); err != nil {
 		return err
 	}
 
@@ -100,6 +286,25 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		os.Exit(1)
 	}
 
+	// Run only the selected job, its dependencies count as completed
+	if p.opts.NoDeps {
+		target := job
+		if target == "" {
+			target = "default"
+		}
+		if _, ok := allJobs[target]; !ok {
+			return fmt.Errorf("no-deps requires a job to run, no default job found")
+		}
+		deps := GetDependencies(allJobs[target].DependsOn)
+		for _, dep := range deps {
+			pipelineCtx.MarkJobCompleted(dep)
+		}
+		if len(deps) > 0 {
+			fmt.Fprintf(os.Stderr, "%s skipping dependencies of %s, their outputs are not available: %s\n", colors.BrightYellow("!"), target, strings.Join(deps, ", "))
+		}
+		jobOrder = []string{target}
+	}
+
 	// Pre-populate all jobs as pending - include all jobs that might be invoked
 	jobNodes := make(map[string]*treeview.TreeNode)
 	jobsToCreate := make(map[string]bool)
@@ -147,13 +352,33 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		}
 	}
 
+	// Add a job node for steps run outside of the job order
+	addFinalNode := func(finalJob *model.Job) *treeview.TreeNode {
+		finalNode := tree.AddJobWithoutSteps(nil, finalJob.Name, false)
+		finalNode.ID = "jobs." + finalJob.Name
+		for _, step := range finalJob.Children() {
+			finalNode.AddChild(newStepNode(step))
+		}
+		return finalNode
+	}
+
+	// before_all and after_all render as siblings around the jobs
+	var (
+		beforeAllJob, afterAllJob   *model.Job
+		beforeAllNode, afterAllNode *treeview.TreeNode
+	)
+	if len(pipeline.BeforeAll) > 0 {
+		beforeAllJob = &model.Job{Name: "before_all", Steps: pipeline.BeforeAll}
+		beforeAllNode = addFinalNode(beforeAllJob)
+	}
+
 	// Create job nodes for all jobs that might be invoked
 	// Only add root-level jobs to the tree display; nested jobs are added when invoked as tasks
 	jobsToCreateSorted := treeview.SortByOrder(jobsToCreate, jobOrder)
 	for _, jobName := range jobsToCreateSorted {
 		job := allJobs[jobName]
 		jobLabel := jobName
-		if job.Desc != "" {
+		if job.Desc != "" && !p.opts.DescLines {
 			jobLabel = jobName + " - " + job.Desc
 		}
 
@@ -176,20 +401,14 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		if isRootJob {
 			jobNode := tree.AddJobWithoutSteps(deps, jobLabel, job.Nested)
 			jobNode.Summarize = job.Summarize
+			jobNode.ID = "jobs." + jobName
+			if p.opts.DescLines {
+				jobNode.Desc = job.Desc
+			}
 
 			if !isSimpleTask {
 				for _, step := range steps {
-					stepNode := treeview.NewPendingStepNode(step.DisplayLabel(), step.IsDeferred(), step.Summarize)
-
-					// If step has multiple commands, create child nodes for each command
-					stepchildren := step.Cmds
-					if len(stepchildren) > 0 {
-						for _, cmd := range stepchildren {
-							stepNode.AddChild(treeview.NewCmdNode(cmd))
-						}
-					}
-
-					jobNode.AddChild(stepNode)
+					jobNode.AddChild(newStepNode(step))
 				}
 			}
 
@@ -198,20 +417,14 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 			// For non-root jobs (only invoked as tasks), create nodes but don't add to tree
 			jobNode := treeview.NewNode(jobLabel)
 			jobNode.Summarize = job.Summarize
+			jobNode.ID = "jobs." + jobName
+			if p.opts.DescLines {
+				jobNode.Desc = job.Desc
+			}
 
 			if !isSimpleTask {
 				for _, step := range steps {
-					stepNode := treeview.NewPendingStepNode(step.DisplayLabel(), step.IsDeferred(), step.Summarize)
-
-					// If step has multiple commands, create child nodes for each command
-					stepchildren := step.Cmds
-					if len(stepchildren) > 0 {
-						for _, cmd := range stepchildren {
-							stepNode.AddChild(treeview.NewCmdNode(cmd))
-						}
-					}
-
-					jobNode.AddChild(stepNode)
+					jobNode.AddChild(newStepNode(step))
 				}
 			}
 
@@ -219,14 +432,81 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		}
 	}
 	pipelineCtx.JobNodes = jobNodes
+
+	if len(pipeline.AfterAll) > 0 {
+		afterAllJob = &model.Job{Name: "after_all", Steps: pipeline.AfterAll}
+		afterAllNode = addFinalNode(afterAllJob)
+	}
 	display.Render(root)
 
-	executor := NewExecutor()
+	// The on-fail job runs when the pipeline fails, the option overrides on_failure_job
+	onFail := pipeline.OnFailureJob
+	if p.opts.OnFail != "" {
+		onFail = p.opts.OnFail
+	}
+	var onFailJob *model.Job
+	if onFail != "" {
+		onFailJob = allJobs[onFail]
+		if onFailJob == nil {
+			return fmt.Errorf("on-fail job %q not found in pipeline", onFail)
+		}
+	}
+
+	executorOpts := DefaultOptions()
+	executorOpts.FailOnEmpty = p.opts.FailOnEmpty
+	if p.opts.MaxDepth > 0 {
+		executorOpts.MaxTaskDepth = p.opts.MaxDepth
+	}
+	if p.opts.JobTimeout > 0 {
+		executorOpts.DefaultTimeout = p.opts.JobTimeout
+	}
+	if p.opts.Steps != nil {
+		stepsJob := job
+		if stepsJob == "" {
+			stepsJob = "default"
+		}
+		if _, ok := allJobs[stepsJob]; !ok {
+			return fmt.Errorf("step selection requires a job to run, no default job found")
+		}
+		executorOpts.Steps = p.opts.Steps
+		executorOpts.StepsJob = stepsJob
+	}
+	executor := NewExecutorWithOptions(executorOpts)
 
 	// Track job results (completion is tracked via pipelineCtx.JobCompleted)
 	jobResults := make(map[string]*ExecutionContext)
+	jobOutputs := make(map[string]map[string]any)
 	var jobMutex sync.Mutex
 
+	// needs exposes the outputs of completed dependencies as needs.<job>.outputs
+	needs := func(deps []string) map[string]any {
+		jobMutex.Lock()
+		defer jobMutex.Unlock()
+
+		result := make(map[string]any, len(deps))
+		for _, dep := range deps {
+			outputs := jobOutputs[dep]
+			if outputs == nil {
+				outputs = map[string]any{}
+			}
+			result[dep] = map[string]any{"outputs": outputs}
+		}
+		return result
+	}
+
+	// With MaxFailures, jobs run with a context cancelled once that many
+	// detached jobs failed. Final jobs run without cancellation.
+	var failures atomic.Int32
+	cancelJobs := func(error) {}
+	maxFailures := func() bool { return false }
+	if p.opts.MaxFailures > 0 {
+		ctx, cancelJobs = context.WithCancelCause(ctx)
+		defer cancelJobs(nil)
+		maxFailures = func() bool {
+			return errors.Is(context.Cause(ctx), errMaxFailures)
+		}
+	}
+
 	// Helper to execute a job (with dependency checking)
 	executeJobWithDeps := func(jobName string, job *model.Job) error {
 		// Wait for dependencies if any
@@ -240,40 +520,131 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 			}
 		}
 
-		jobCtx := pipelineCtx.Copy()
-		jobCtx.Job = job
-		jobCtx.Depth = 1
-		jobCtx.StepSequence = 0 // Reset step counter for each job
-
-		// Get pre-created job node and mark it as running
+		// Get pre-created job node and snapshot it so retries can reset the subtree
 		jobNode := jobNodes[jobName]
-		jobNode.SetStatus(treeview.StatusRunning)
-		jobCtx.CurrentJob = jobNode
 
-		// Capture job start time
-		var jobStartOffset float64
-		if logger != nil {
-			jobStartOffset = logger.GetElapsed()
+		jobID := "jobs." + jobName
+
+		// Jobs don't start once too many jobs failed
+		if maxFailures() {
+			jobNode.SetStatus(treeview.StatusSkipped)
+			jobNode.SetSkipReason("max failures reached")
+			logger.LogExec(eventlog.ResultSkipped, jobID, jobName, logger.GetElapsed(), 0, nil)
+			display.Render(root)
+			pipelineCtx.MarkJobCompleted(jobName)
+			return nil
 		}
-		jobNode.Node.SetStartOffset(jobStartOffset)
-		jobStartTime := time.Now()
 
-		display.Render(root)
+		// Skip the job if any of its if_exists paths are missing
+		if len(job.IfExists) > 0 {
+			exists, err := pathsExist(pipeline.Dir, job.IfExists)
+			if err != nil {
+				pipelineCtx.MarkJobCompleted(jobName)
+				return err
+			}
+			if !exists {
+				jobNode.SetStatus(treeview.StatusSkipped)
+				jobNode.SetSkipReason("if_exists path missing")
+				logger.LogExec(eventlog.ResultSkipped, jobID, jobName, logger.GetElapsed(), 0, nil)
+				display.Render(root)
+				pipelineCtx.MarkJobCompleted(jobName)
+				return nil
+			}
+		}
 
-		execErr := executor.ExecuteJob(ctx, jobCtx)
+		// Skip the job if none of its run_if_changed files changed since the last success
+		runStart := time.Now()
+		if pipelineCtx.ChangeState != nil && len(job.RunIfChanged) > 0 {
+			changed, err := pipelineCtx.ChangeState.Changed(jobID, job.RunIfChanged)
+			if err != nil {
+				pipelineCtx.MarkJobCompleted(jobName)
+				return err
+			}
+			if !changed {
+				jobNode.SetStatus(treeview.StatusSkipped)
+				jobNode.SetSkipReason("unchanged")
+				logger.LogExec(eventlog.ResultSkipped, jobID, jobName, logger.GetElapsed(), 0, nil)
+				display.Render(root)
+				pipelineCtx.MarkJobCompleted(jobName)
+				return nil
+			}
+		}
 
-		// Calculate job duration
-		jobDuration := time.Since(jobStartTime)
-		jobNode.Node.SetDuration(jobDuration.Seconds())
+		snapshot := treeview.NewSnapshot(jobNode.Node)
 
-		// Log job event
-		jobID := "jobs." + jobName
-		if logger != nil {
-			result := eventlog.ResultPass
-			if execErr != nil {
-				result = eventlog.ResultFail
+		// Bound all attempts by the job timeout if configured to do so
+		jobRunCtx := ctx
+		if job.Retries > 0 && job.TimeoutMode == model.TimeoutModeTotal {
+			var cancel context.CancelFunc
+			jobRunCtx, cancel = context.WithTimeout(ctx, parseTimeout(job.Timeout, executor.opts.DefaultTimeout))
+			defer cancel()
+		}
+
+		var (
+			jobCtx   *ExecutionContext
+			outputs  map[string]any
+			execErr  error
+			attempts = job.Retries + 1
+		)
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if attempt > 1 {
+				snapshot.Restore()
+				display.Render(root)
+
+				if err := sleepContext(jobRunCtx, retryDelay(job, attempt-1)); err != nil {
+					execErr = fmt.Errorf("job %q retry cancelled: %w", jobName, err)
+					break
+				}
+			}
+
+			jobCtx = pipelineCtx.Copy()
+			jobCtx.Job = job
+			jobCtx.Depth = 1
+			jobCtx.StepSequence = 0 // Reset step counter for each job
+			jobCtx.Variables["needs"] = needs(deps)
+
+			// Mark the job node as running
+			jobNode.SetStatus(treeview.StatusRunning)
+			jobCtx.CurrentJob = jobNode
+
+			// Capture job start time
+			var jobStartOffset float64
+			if logger != nil {
+				jobStartOffset = logger.GetElapsed()
+			}
+			jobNode.Node.SetStartOffset(jobStartOffset)
+			jobStartTime := time.Now()
+
+			display.Render(root)
+
+			execErr = executor.ExecuteJob(jobRunCtx, jobCtx)
+			if execErr == nil {
+				outputs, execErr = interpolateVariables(jobCtx, job.Outputs)
+				if execErr != nil {
+					execErr = fmt.Errorf("job %q outputs: %w", jobName, execErr)
+				}
+			}
+
+			// Calculate job duration
+			jobDuration := time.Since(jobStartTime)
+			jobNode.Node.SetDuration(jobDuration.Seconds())
+
+			// Log job event, one per attempt
+			if logger != nil {
+				result := eventlog.ResultPass
+				if execErr != nil {
+					result = eventlog.ResultFail
+				}
+				run := jobName
+				if attempts > 1 {
+					run = fmt.Sprintf("%s (attempt %d/%d)", jobName, attempt, attempts)
+				}
+				logger.LogExec(result, jobID, run, jobStartOffset, jobDuration.Milliseconds(), execErr)
+			}
+
+			if execErr == nil {
+				break
 			}
-			logger.LogExec(result, jobID, jobName, jobStartOffset, jobDuration.Milliseconds(), execErr)
 		}
 
 		if execErr != nil {
@@ -281,73 +652,278 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 			return execErr
 		}
 
+		if pipelineCtx.ChangeState != nil && len(job.RunIfChanged) > 0 {
+			if err := pipelineCtx.ChangeState.MarkSuccess(jobID, runStart); err != nil {
+				pipelineCtx.MarkJobCompleted(jobName)
+				return err
+			}
+		}
+
 		// Mark job as passed
 		jobNode.SetStatus(treeview.StatusPassed)
 		display.Render(root)
 
-		// Store results
-		pipelineCtx.MarkJobCompleted(jobName)
+		// Store results before marking completion, so dependents see the outputs
 		jobMutex.Lock()
 		jobResults[jobName] = jobCtx
+		jobOutputs[jobName] = outputs
 		jobMutex.Unlock()
+		pipelineCtx.MarkJobCompleted(jobName)
 
 		return nil
 	}
 
-	eg := new(errgroup.Group)
+	// Run a job once outside of the job order, with variables set in its scope.
+	runFinalJob := func(runCtx context.Context, finalJob *model.Job, finalNode *treeview.TreeNode, variables map[string]any) error {
+		finalCtx := pipelineCtx.Copy()
+		finalCtx.Job = finalJob
+		finalCtx.Depth = 1
+		finalCtx.StepSequence = 0
+		finalCtx.CurrentJob = finalNode
+		for k, v := range variables {
+			finalCtx.Variables[k] = v
+		}
+
+		finalNode.SetStatus(treeview.StatusRunning)
+		display.Render(root)
+
+		start := time.Now()
+		startOffset := logger.GetElapsed()
+		err := executor.ExecuteJob(runCtx, finalCtx)
+		duration := time.Since(start)
+		finalNode.Node.SetDuration(duration.Seconds())
+
+		result := eventlog.ResultPass
+		if err != nil {
+			result = eventlog.ResultFail
+			finalNode.SetStatus(treeview.StatusFailed)
+		} else {
+			finalNode.SetStatus(treeview.StatusPassed)
+		}
+		logger.LogExec(result, "jobs."+finalJob.Name, finalJob.Name, startOffset, duration.Milliseconds(), err)
+		return err
+	}
+
+	// Run the pipeline post_run steps once, after all jobs, pass or fail.
+	// The steps see the pipeline result as ${{ result }}. Failures are
+	// reported, but don't override the result of the pipeline.
+	runPostRun := func(runErr error) {
+		if len(pipeline.PostRun) == 0 {
+			return
+		}
+
+		postJob := &model.Job{
+			Name:  "post_run",
+			Steps: pipeline.PostRun,
+		}
+		result := "success"
+		if runErr != nil {
+			result = "failure"
+		}
+		if err := runFinalJob(context.WithoutCancel(ctx), postJob, addFinalNode(postJob), map[string]any{"result": result}); err != nil {
+			fmt.Fprintf(os.Stderr, "%s post_run failed: %s\n", colors.BrightRed("ERROR:"), err)
+		}
+	}
+
+	// Run the on-fail job once if the pipeline failed, with the first failed
+	// job as ${{ failed_job }}. Its failure is reported, the run error is kept.
+	var failedJob string
+	setFailedJob := func(name string) {
+		jobMutex.Lock()
+		defer jobMutex.Unlock()
+		if failedJob == "" {
+			failedJob = name
+		}
+	}
+	runOnFail := func(runErr error) {
+		if runErr == nil || onFailJob == nil {
+			return
+		}
+
+		jobMutex.Lock()
+		variables := map[string]any{"failed_job": failedJob}
+		jobMutex.Unlock()
+
+		if err := runFinalJob(context.WithoutCancel(ctx), onFailJob, addFinalNode(onFailJob), variables); err != nil {
+			fmt.Fprintf(os.Stderr, "%s on-fail job %q failed: %s\n", colors.BrightRed("ERROR:"), onFailJob.Name, err)
+		}
+	}
+
+	// Run the after_all steps once after all jobs, pass or fail. They run
+	// even if the pipeline was cancelled, so cleanup still happens. A
+	// failure fails a passing run, the first error is kept otherwise.
+	runAfterAll := func(runErr error) error {
+		if afterAllJob == nil {
+			return runErr
+		}
+		err := runFinalJob(context.WithoutCancel(ctx), afterAllJob, afterAllNode, nil)
+		if err != nil && runErr == nil {
+			setFailedJob(afterAllJob.Name)
+			return err
+		}
+		return runErr
+	}
+
+	// Detached jobs all run to completion, a failing one doesn't cancel
+	// the others. Their errors are joined in job order.
+	var detachedWg sync.WaitGroup
+	detachedErrs := make([]error, len(jobOrder))
+
+	// Finish a failed run, running the final jobs and writing the reports.
+	// Detached jobs still running are waited on first.
+	failRun := func(err error) error {
+		detachedWg.Wait()
+		err = errors.Join(err, errors.Join(detachedErrs...))
+		err = runAfterAll(err)
+		runOnFail(err)
+		runPostRun(err)
+		root.SetStatus(treeview.StatusFailed)
+		display.Render(root)
+
+		// If not a TTY, print final tree at the end
+		if !display.IsTerminal() {
+			display.RenderStatic(root)
+		}
+
+		p.writeGitHubAnnotations(logger)
+		p.writeFailures(logger, root)
+		p.writeSkips(root)
+
+		// Write event log on failure
+		writeEventLog(logger, root, err)
+		p.writeHTMLReport(logger, root, err)
+		p.complete(root, time.Since(start), err)
+
+		return err
+	}
+
+	// No job runs if before_all fails
+	if beforeAllJob != nil {
+		if err := runFinalJob(ctx, beforeAllJob, beforeAllNode, nil); err != nil {
+			if ctx.Err() != nil {
+				root.FailRunning()
+			}
+			setFailedJob(beforeAllJob.Name)
+			return failRun(err)
+		}
+	}
+
 	detached := 0
 	count := 0
 
-	for _, name := range jobOrder {
+	// Detached jobs are dispatched in batches, up to the next job that isn't
+	// detached, highest priority first. With MaxParallel, a job takes a slot
+	// once its dependencies completed, so waiting jobs don't hold slots.
+	type pendingJob struct {
+		index int
+		name  string
+		job   *model.Job
+	}
+	var (
+		pending []pendingJob
+		slots   chan struct{}
+	)
+	if p.opts.MaxParallel > 0 {
+		slots = make(chan struct{}, p.opts.MaxParallel)
+	}
+	depsCompleted := func(pj pendingJob) bool {
+		for _, dep := range GetDependencies(pj.job.DependsOn) {
+			if !pipelineCtx.IsJobCompleted(dep) {
+				return false
+			}
+		}
+		return true
+	}
+	dispatch := func() {
+		slices.SortStableFunc(pending, func(a, b pendingJob) int {
+			return b.job.Priority - a.job.Priority
+		})
+		for len(pending) > 0 {
+			next := 0
+			if slots != nil {
+				slots <- struct{}{}
+				next = slices.IndexFunc(pending, depsCompleted)
+				for next < 0 {
+					time.Sleep(50 * time.Millisecond)
+					next = slices.IndexFunc(pending, depsCompleted)
+				}
+			}
+			pj := pending[next]
+			pending = slices.Delete(pending, next, next+1)
+
+			detachedWg.Go(func() {
+				if slots != nil {
+					defer func() { <-slots }()
+				}
+				if err := executeJobWithDeps(pj.name, pj.job); err != nil {
+					setFailedJob(pj.name)
+					detachedErrs[pj.index] = err
+					if n := int(failures.Add(1)); n == p.opts.MaxFailures {
+						cancelJobs(fmt.Errorf("%w: %d jobs failed", errMaxFailures, n))
+					}
+				}
+			})
+		}
+	}
+
+	for i, name := range jobOrder {
 		job := allJobs[name]
 
 		if job == nil {
 			return fmt.Errorf("job %q not found in pipeline", name)
 		}
 
-		if job.Detach {
+		detach, err := EvaluateDetach(job.Detach, pipelineCtx)
+		if err == nil && detach {
 			detached++
 			count++
-			// Capture job and name by value to avoid closure variable capture issues
-			jobCopy := job
-			nameCopy := name
-			eg.Go(func() error {
-				return executeJobWithDeps(nameCopy, jobCopy)
-			})
+			pending = append(pending, pendingJob{index: i, name: name, job: job})
 			continue
 		}
 
-		if err := executeJobWithDeps(name, job); err != nil {
-			root.SetStatus(treeview.StatusFailed)
-			display.Render(root)
-
-			// If not a TTY, print final tree at the end
-			if !display.IsTerminal() {
-				display.RenderStatic(root)
+		dispatch()
+		if err == nil {
+			err = executeJobWithDeps(name, job)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				root.FailRunning()
 			}
-
-			// Write event log on failure
-			writeEventLog(logger, root, err)
-
-			return err
+			setFailedJob(name)
+			return failRun(err)
 		}
 		count++
 	}
 
 	// Wait for all detached jobs
+	dispatch()
 	var runErr error
 	if detached > 0 {
-		if err := eg.Wait(); err != nil {
+		detachedWg.Wait()
+		if err := errors.Join(detachedErrs...); err != nil {
+			if maxFailures() {
+				err = fmt.Errorf("%w\n%w", context.Cause(ctx), err)
+			}
+
 			// Mark pipeline as failed
+			if ctx.Err() != nil {
+				root.FailRunning()
+			}
 			root.SetStatus(treeview.StatusFailed)
 			display.Render(root)
 			runErr = err
 		}
 	}
 
+	runErr = runAfterAll(runErr)
+	runOnFail(runErr)
+	runPostRun(runErr)
+
 	if runErr == nil {
 		// Mark pipeline as passed and render final tree
 		root.SetStatus(treeview.StatusPassed)
+	} else {
+		root.SetStatus(treeview.StatusFailed)
 	}
 	display.Render(root)
 
@@ -356,12 +932,89 @@ func (p *Pipeline) runPipeline(ctx context.Context, logger *eventlog.Logger) err
 		display.RenderStatic(root)
 	}
 
+	p.writeGitHubAnnotations(logger)
+	if runErr != nil {
+		p.writeFailures(logger, root)
+	}
+	p.writeSkips(root)
+
 	// Write event log
 	writeEventLog(logger, root, runErr)
+	p.writeHTMLReport(logger, root, runErr)
+	p.complete(root, time.Since(start), runErr)
 
 	return runErr
 }
 
+// writeGitHubAnnotations prints the logged events as GitHub Actions
+// workflow commands, if enabled.
+func (p *Pipeline) writeGitHubAnnotations(logger *eventlog.Logger) {
+	if !p.opts.GitHubAnnotations || logger == nil {
+		return
+	}
+
+	// Annotations are matched against paths relative to the repository
+	file := p.opts.PipelineFile
+	if filepath.IsAbs(file) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, file); err == nil {
+				file = rel
+			}
+		}
+	}
+
+	if err := eventlog.WriteGitHubAnnotations(os.Stdout, file, logger.GetEvents()); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed to write GitHub annotations: %s\n", colors.BrightYellow("!"), err)
+	}
+}
+
+// writeFailures prints the output of failed steps to stderr, if enabled.
+func (p *Pipeline) writeFailures(logger *eventlog.Logger, root *treeview.Node) {
+	if !p.opts.OnlyFailedOutput || logger == nil {
+		return
+	}
+	failures := CollectFailures(root, logger.GetEvents())
+	for i := range failures {
+		failures[i].Name = p.opts.Redactor.Redact(failures[i].Name)
+	}
+	WriteFailures(os.Stderr, failures)
+}
+
+// writeSkips prints the skipped steps and their reasons to stderr, if enabled.
+func (p *Pipeline) writeSkips(root *treeview.Node) {
+	if !p.opts.ExplainSkips {
+		return
+	}
+	skips := CollectSkips(root)
+	for i := range skips {
+		skips[i].Name = p.opts.Redactor.Redact(skips[i].Name)
+	}
+	WriteSkips(os.Stderr, skips)
+}
+
+// writeHTMLReport writes the run tree as an HTML page, if a report file is set.
+func (p *Pipeline) writeHTMLReport(logger *eventlog.Logger, root *treeview.Node, runErr error) {
+	if p.opts.ReportHTML == "" || logger == nil {
+		return
+	}
+
+	state, summary := runSummary(root, logger.GetElapsed(), runErr)
+	if p.opts.Redactor != nil {
+		eventlog.RedactState(state, p.opts.Redactor.Redact)
+	}
+
+	f, err := os.Create(p.opts.ReportHTML)
+	if err == nil {
+		err = eventlog.WriteHTMLReport(f, p.data.Name, state, summary, logger.GetEvents())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed to write HTML report: %s\n", colors.BrightYellow("!"), err)
+	}
+}
+
 // writeEventLog writes the final event log to the file.
 func writeEventLog(logger *eventlog.Logger, root *treeview.Node, runErr error) {
 	if logger == nil {
@@ -371,6 +1024,12 @@ func writeEventLog(logger *eventlog.Logger, root *treeview.Node, runErr error) {
 	// Set root duration
 	root.SetDuration(logger.GetElapsed())
 
+	state, summary := runSummary(root, logger.GetElapsed(), runErr)
+	logger.Write(state, summary)
+}
+
+// runSummary converts the tree to state and builds the run summary from the step counts.
+func runSummary(root *treeview.Node, duration float64, runErr error) (*eventlog.StateNode, *eventlog.RunSummary) {
 	// Convert tree to state
 	state := eventlog.NodeToStateNode(root)
 
@@ -384,7 +1043,7 @@ func writeEventLog(logger *eventlog.Logger, root *treeview.Node, runErr error) {
 
 	stats := eventlog.CaptureRuntimeStats()
 	summary := &eventlog.RunSummary{
-		Duration:     logger.GetElapsed(),
+		Duration:     duration,
 		TotalSteps:   total,
 		PassedSteps:  passed,
 		FailedSteps:  failed,
@@ -393,8 +1052,95 @@ func writeEventLog(logger *eventlog.Logger, root *treeview.Node, runErr error) {
 		MemoryAlloc:  stats.MemoryAlloc,
 		Goroutines:   stats.Goroutines,
 	}
+	return state, summary
+}
 
-	logger.Write(state, summary)
+// complete prints a one-line run summary to stderr and calls
+// the OnComplete hook, if enabled.
+func (p *Pipeline) complete(root *treeview.Node, duration time.Duration, runErr error) {
+	if !p.opts.Summary && p.opts.OnComplete == nil {
+		return
+	}
+
+	state, summary := runSummary(root, duration.Seconds(), runErr)
+	if p.opts.Summary {
+		fmt.Fprintln(os.Stderr, FormatSummary(len(state.Children), summary))
+	}
+	if p.opts.OnComplete != nil {
+		p.opts.OnComplete(len(state.Children), summary)
+	}
+}
+
+// FormatSummary formats a run summary as a single line, e.g.
+// `atkins: 3 jobs, 27 steps (25 passed, 1 failed, 1 skipped) in 42.3s`.
+func FormatSummary(jobs int, summary *eventlog.RunSummary) string {
+	passed := colors.Green(fmt.Sprintf("%d passed", summary.PassedSteps))
+	failed := fmt.Sprintf("%d failed", summary.FailedSteps)
+	if summary.FailedSteps > 0 {
+		failed = colors.BrightRed(failed)
+	}
+	skipped := fmt.Sprintf("%d skipped", summary.SkippedSteps)
+	if summary.SkippedSteps > 0 {
+		skipped = colors.BrightYellow(skipped)
+	}
+
+	return fmt.Sprintf("atkins: %s, %s (%s, %s, %s) in %.1fs",
+		plural(jobs, "job"), plural(summary.TotalSteps, "step"), passed, failed, skipped, summary.Duration)
+}
+
+// plural formats a count with a singular or plural noun.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// newPipelineContext returns the root execution context of a run of
+// pipeline, with the job args set and the OS environment and pipeline
+// declarations merged. The caller sets up the display and logging.
+func newPipelineContext(ctx context.Context, pipeline *model.Pipeline, opts PipelineOptions) (*ExecutionContext, error) {
+	pipelineCtx := &ExecutionContext{
+		Variables:           make(map[string]any),
+		Env:                 make(map[string]string),
+		Results:             make(map[string]any),
+		Pipeline:            pipeline,
+		Context:             ctx,
+		JobNodes:            make(map[string]*treeview.TreeNode),
+		JobCompleted:        make(map[string]bool),
+		CommandCache:        NewCommandCache(),
+		Redactor:            opts.Redactor,
+		Profile:             opts.Profile,
+		StrictEnv:           opts.StrictEnv,
+		SubstitutionTimeout: opts.SubstitutionTimeout,
+	}
+
+	args := make([]any, 0, len(opts.Args))
+	for _, arg := range opts.Args {
+		args = append(args, arg)
+	}
+	pipelineCtx.Variables["args"] = args
+
+	// Copy environment variables from OS, only allowed ones if restricted
+	copyOSEnv(pipelineCtx, append(slices.Clone(pipeline.EnvPassthrough), opts.EnvPassthrough...))
+
+	if err := MergeVariables(pipeline.Decl, pipelineCtx); err != nil {
+		return nil, err
+	}
+	return pipelineCtx, nil
+}
+
+// copyOSEnv copies the OS environment into ctx. If passthrough is
+// not empty, only those variables are copied and the env is isolated.
+func copyOSEnv(ctx *ExecutionContext, passthrough []string) {
+	ctx.EnvIsolated = len(passthrough) > 0
+	for _, env := range os.Environ() {
+		k, v := parseEnv(env)
+		if k == "" || (ctx.EnvIsolated && !slices.Contains(passthrough, k)) {
+			continue
+		}
+		ctx.Env[k] = v
+	}
 }
 
 func indent(depth int) string {
@@ -409,3 +1155,17 @@ func parseEnv(env string) (string, string) {
 	}
 	return "", ""
 }
+
+// newStepNode creates the pending tree node for a step. Steps with
+// multiple commands get a child node for each command, and parallel
+// steps get a child node for each of their steps.
+func newStepNode(step *model.Step) *treeview.Node {
+	stepNode := treeview.NewPendingStepNode(step.DisplayLabel(), step.IsDeferred(), step.Summarize)
+	for _, cmd := range step.Cmds {
+		stepNode.AddChild(treeview.NewCmdNode(cmd))
+	}
+	for _, child := range step.Parallel {
+		stepNode.AddChild(newStepNode(child))
+	}
+	return stepNode
+}
@@ -0,0 +1,302 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// Plan decisions for steps.
+const (
+	PlanRun     = "run"
+	PlanSkip    = "skip"
+	PlanUnknown = "unknown"
+)
+
+// Plan is the resolved execution plan of a pipeline.
+type Plan struct {
+	Pipeline string     `json:"pipeline"`
+	Jobs     []*PlanJob `json:"jobs"`
+}
+
+// PlanJob is a job in the execution plan.
+type PlanJob struct {
+	Name      string      `json:"name"`
+	DependsOn []string    `json:"depends_on,omitempty"`
+	Steps     []*PlanStep `json:"steps"`
+}
+
+// PlanStep is a step in the execution plan. For loops are expanded
+// into Iterations, each with the commands of that iteration.
+type PlanStep struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Task       string           `json:"task,omitempty"`
+	If         string           `json:"if,omitempty"`
+	For        string           `json:"for,omitempty"`
+	Decision   string           `json:"decision"`
+	Commands   []string         `json:"commands,omitempty"`
+	Iterations []*PlanIteration `json:"iterations,omitempty"`
+	Unresolved bool             `json:"unresolved,omitempty"` // Contains $(...) that was not executed
+}
+
+// PlanIteration is a single for loop iteration in the execution plan.
+type PlanIteration struct {
+	Variables  map[string]any `json:"variables"`
+	Decision   string         `json:"decision"`
+	Commands   []string       `json:"commands,omitempty"`
+	Unresolved bool           `json:"unresolved,omitempty"`
+}
+
+// DryRunPipeline prints the execution plan for job without running it.
+// JSON is currently the only supported format.
+func DryRunPipeline(pipeline *model.Pipeline, job, format string) error {
+	if format != "" && format != "json" {
+		return fmt.Errorf("unknown dry-run format %q (expected json)", format)
+	}
+
+	plan, err := BuildPlan(pipeline, job)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
+
+// BuildPlan resolves the jobs that would run for job (or the default
+// job if empty), without executing anything. Command substitutions
+// $(...) are never run; values containing them are left as-is and the
+// step is flagged as unresolved.
+func BuildPlan(pipeline *model.Pipeline, job string) (*Plan, error) {
+	allJobs := pipeline.Jobs
+	if len(allJobs) == 0 {
+		allJobs = pipeline.Tasks
+	}
+
+	jobOrder, err := ResolveJobDependencies(allJobs, job)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineCtx := &ExecutionContext{
+		Variables: make(map[string]any),
+		Env:       make(map[string]string),
+		Pipeline:  pipeline,
+	}
+	for _, env := range os.Environ() {
+		if k, v := parseEnv(env); k != "" {
+			pipelineCtx.Env[k] = v
+		}
+	}
+	planDecl(pipelineCtx, pipeline.Decl)
+
+	plan := &Plan{
+		Pipeline: pipeline.Name,
+		Jobs:     make([]*PlanJob, 0, len(jobOrder)),
+	}
+	for _, name := range jobOrder {
+		job := allJobs[name]
+		if job == nil {
+			return nil, fmt.Errorf("job %q not found in pipeline", name)
+		}
+
+		jobCtx := pipelineCtx.Copy()
+		jobCtx.Job = job
+		planDecl(jobCtx, job.Decl)
+
+		planJob := &PlanJob{
+			Name:      name,
+			DependsOn: GetDependencies(job.DependsOn),
+			Steps:     make([]*PlanStep, 0, len(job.Children())),
+		}
+		for idx, step := range job.Children() {
+			planJob.Steps = append(planJob.Steps, planStep(jobCtx, name, idx, step))
+		}
+		plan.Jobs = append(plan.Jobs, planJob)
+	}
+
+	return plan, nil
+}
+
+// planStep resolves a single step, expanding for loops where possible.
+func planStep(jobCtx *ExecutionContext, jobName string, idx int, step *model.Step) *PlanStep {
+	ctx := jobCtx.Copy()
+	ctx.Step = step
+	planDecl(ctx, step.Decl)
+
+	result := &PlanStep{
+		ID:   generateStepID(jobName, idx),
+		Name: step.DisplayLabel(),
+		Task: step.Task,
+		If:   step.If,
+		For:  step.For,
+	}
+
+	if step.For == "" {
+		result.Decision = planDecision(ctx)
+		result.Commands, result.Unresolved = planCommands(ctx, step)
+		return result
+	}
+
+	itemsVar, loopVar, indexVar, keyVar, err := parseForPattern(step.For)
+	if err != nil || forItemsUnresolved(ctx, itemsVar) {
+		result.Decision = PlanUnknown
+		result.Unresolved = true
+		return result
+	}
+
+	iterations, err := ExpandFor(ctx, func(string) (string, error) {
+		return "", fmt.Errorf("command substitution is not executed in a plan")
+	})
+	if err != nil {
+		result.Decision = PlanUnknown
+		result.Unresolved = true
+		return result
+	}
+
+	result.Decision = PlanSkip
+	for _, iteration := range iterations {
+		iterCtx := ctx.Copy()
+		for k, v := range iteration.Variables {
+			iterCtx.Variables[k] = v
+		}
+
+		// Only report the loop variables, not everything in scope
+		loopVars := make(map[string]any)
+		for _, name := range []string{loopVar, indexVar, keyVar} {
+			if v, ok := iteration.Variables[name]; ok && name != "" {
+				loopVars[name] = v
+			}
+		}
+
+		planIteration := &PlanIteration{
+			Variables: loopVars,
+			Decision:  planDecision(iterCtx),
+		}
+		planIteration.Commands, planIteration.Unresolved = planCommands(iterCtx, step)
+		if planIteration.Decision != PlanSkip {
+			result.Decision = planIteration.Decision
+		}
+		result.Unresolved = result.Unresolved || planIteration.Unresolved
+		result.Iterations = append(result.Iterations, planIteration)
+	}
+	return result
+}
+
+// forItemsUnresolved returns true if the for loop items are a value that
+// still contains a command substitution, e.g. from an unresolved variable.
+func forItemsUnresolved(ctx *ExecutionContext, itemsSpec string) bool {
+	itemsSpec = strings.TrimSpace(itemsSpec)
+	if m := wholeExpressionRegex.FindStringSubmatch(itemsSpec); m != nil {
+		itemsSpec = m[1]
+	}
+	val, err := evaluateExpression(strings.TrimSpace(itemsSpec), ctx)
+	if err != nil {
+		return false
+	}
+	s, ok := val.(string)
+	return ok && hasCommandSubstitution(s)
+}
+
+// planDecision evaluates the step condition as run, skip or unknown.
+func planDecision(ctx *ExecutionContext) string {
+	ok, err := EvaluateIf(ctx)
+	switch {
+	case err != nil:
+		return PlanUnknown
+	case ok:
+		return PlanRun
+	default:
+		return PlanSkip
+	}
+}
+
+// planCommands interpolates the step commands without running $(...).
+func planCommands(ctx *ExecutionContext, step *model.Step) ([]string, bool) {
+	commands, err := stepCommands(ctx, step)
+	if err != nil {
+		return nil, true
+	}
+	if len(commands) == 0 {
+		return nil, false
+	}
+
+	unresolved := false
+	result := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		interpolated, _ := interpolateVariablesInString(cmd, ctx)
+		if hasCommandSubstitution(interpolated) {
+			unresolved = true
+		}
+		result = append(result, interpolated)
+	}
+	return result, unresolved
+}
+
+// planDecl merges the vars and env of decl into ctx without running $(...).
+// Values with command substitutions are kept as written.
+func planDecl(ctx *ExecutionContext, decl *model.Decl) {
+	if decl == nil {
+		return
+	}
+
+	for k, v := range planVariables(ctx, decl.Vars) {
+		ctx.Variables[k] = v
+	}
+	if decl.Env != nil {
+		for k, v := range planVariables(ctx, decl.Env.Vars) {
+			if s, err := envString(v); err == nil {
+				ctx.Env[k] = s
+			}
+		}
+	}
+}
+
+// planVariables resolves vars in dependency order, like interpolateVariables,
+// but leaves values with command substitutions unevaluated.
+func planVariables(ctx *ExecutionContext, vars map[string]any) map[string]any {
+	deps := make(map[string][]string)
+	for k, v := range vars {
+		if s, ok := v.(string); ok {
+			deps[k] = extractVariableDependencies(k, s, vars)
+		} else {
+			deps[k] = nil
+		}
+	}
+
+	order, err := topologicalSort(deps)
+	if err != nil {
+		return vars
+	}
+
+	workCtx := &ExecutionContext{
+		Variables:           copyMap(ctx.Variables),
+		Env:                 ctx.Env,
+		SubstitutionTimeout: ctx.SubstitutionTimeout,
+	}
+	result := make(map[string]any, len(vars))
+	for _, k := range order {
+		v := vars[k]
+		if s, ok := v.(string); ok {
+			if hasCommandSubstitution(s) {
+				v, _ = interpolateVariablesInString(s, workCtx)
+			} else if val, err := InterpolateValue(s, workCtx); err == nil {
+				v = val
+			}
+		}
+		result[k] = v
+		workCtx.Variables[k] = v
+	}
+	return result
+}
+
+// hasCommandSubstitution returns true if s contains a $(...) substitution.
+func hasCommandSubstitution(s string) bool {
+	idx := strings.Index(s, "$(")
+	return idx >= 0 && findMatchingParen(s, idx+2) != -1
+}
@@ -0,0 +1,91 @@
+package runner_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestBuildPlan_JSON(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "executed")
+
+	pipeline := loadTestPipeline(t, `
+name: plan
+vars:
+  targets: [linux, darwin]
+  version: $(touch `+marker+` && echo v1)
+jobs:
+  default:
+    depends_on: setup
+    steps:
+      - run: GOOS=${{ target }} go build
+        for: target in targets
+        if: target != "darwin"
+      - run: echo ${{ version }}
+      - run: echo skipped
+        if: false
+  setup:
+    steps:
+      - task: deps
+  deps:
+    steps:
+      - go mod download
+`)
+
+	plan, err := runner.BuildPlan(pipeline, "")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"pipeline": "plan",
+		"jobs": [
+			{
+				"name": "setup",
+				"steps": [
+					{"id": "jobs.setup.steps.0", "name": "task: deps", "task": "deps", "decision": "run"}
+				]
+			},
+			{
+				"name": "default",
+				"depends_on": ["setup"],
+				"steps": [
+					{
+						"id": "jobs.default.steps.0",
+						"name": "run: GOOS=${{ target }} go build",
+						"if": "target != \"darwin\"",
+						"for": "target in targets",
+						"decision": "run",
+						"iterations": [
+							{"variables": {"target": "linux"}, "decision": "run", "commands": ["GOOS=linux go build"]},
+							{"variables": {"target": "darwin"}, "decision": "skip", "commands": ["GOOS=darwin go build"]}
+						]
+					},
+					{
+						"id": "jobs.default.steps.1",
+						"name": "run: echo ${{ version }}",
+						"decision": "run",
+						"commands": ["echo $(touch `+marker+` && echo v1)"],
+						"unresolved": true
+					},
+					{
+						"id": "jobs.default.steps.2",
+						"name": "run: echo skipped",
+						"if": "false",
+						"decision": "skip",
+						"commands": ["echo skipped"]
+					}
+				]
+			}
+		]
+	}`, string(data))
+
+	// Command substitutions are never executed
+	assert.NoFileExists(t, marker)
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// JobEnv returns the environment the commands of job run with: the OS
+// environment with the pipeline and job env merged over it. No steps
+// are run, but $(...) in env values is. An empty job is the default job.
+func JobEnv(ctx context.Context, pipeline *model.Pipeline, job string, opts PipelineOptions) (map[string]string, error) {
+	allJobs := pipeline.Jobs
+	if len(allJobs) == 0 {
+		allJobs = pipeline.Tasks
+	}
+	if job == "" {
+		job = "default"
+	}
+	jobDef, ok := allJobs[job]
+	if !ok || jobDef == nil {
+		return nil, fmt.Errorf("job %q not found in pipeline", job)
+	}
+
+	pipelineCtx, err := newPipelineContext(ctx, pipeline, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jobCtx := pipelineCtx.Copy()
+	jobCtx.Context = ctx
+	jobCtx.Job = jobDef
+	if err := MergeVariables(jobDef.Decl, jobCtx); err != nil {
+		return nil, fmt.Errorf("job %q: %w", job, err)
+	}
+	return jobCtx.Env, nil
+}
+
+// PrintEnv writes the environment of job to w as KEY=value lines, sorted by key.
+func PrintEnv(ctx context.Context, w io.Writer, pipeline *model.Pipeline, job string, opts PipelineOptions) error {
+	env, err := JobEnv(ctx, pipeline, job, opts)
+	if err != nil {
+		return err
+	}
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		fmt.Fprintf(w, "%s=%s\n", key, opts.Redactor.Redact(env[key]))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package runner_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestPrintEnv(t *testing.T) {
+	t.Setenv("ATKINS_TEST_OS", "os")
+
+	pipeline := loadTestPipeline(t, `
+name: env
+env:
+  vars:
+    LEVEL: pipeline
+    TARGET: linux
+jobs:
+  default:
+    steps:
+      - run: "true"
+  build:
+    env:
+      vars:
+        LEVEL: job
+        OUT: bin/${{ TARGET }}
+    steps:
+      - run: "true"
+`)
+
+	var buf bytes.Buffer
+	require.NoError(t, runner.PrintEnv(t.Context(), &buf, pipeline, "build", runner.PipelineOptions{
+		EnvPassthrough: []string{"ATKINS_TEST_OS"},
+	}))
+	assert.Equal(t, "ATKINS_TEST_OS=os\nLEVEL=job\nOUT=bin/linux\nTARGET=linux\n", buf.String())
+
+	env, err := runner.JobEnv(t.Context(), pipeline, "", runner.PipelineOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "pipeline", env["LEVEL"])
+	assert.Equal(t, "os", env["ATKINS_TEST_OS"])
+	assert.NotContains(t, env, "OUT")
+
+	_, err = runner.JobEnv(t.Context(), pipeline, "missing", runner.PipelineOptions{})
+	assert.ErrorContains(t, err, `job "missing" not found`)
+
+	redactor, err := runner.NewRedactor([]string{"linux"})
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, runner.PrintEnv(t.Context(), &buf, pipeline, "build", runner.PipelineOptions{
+		EnvPassthrough: []string{"ATKINS_TEST_OS"},
+		Redactor:       redactor,
+	}))
+	assert.Equal(t, "ATKINS_TEST_OS=os\nLEVEL=job\nOUT=bin/***\nTARGET=***\n", buf.String())
+}
+
+func TestPrintEnv_Args(t *testing.T) {
+	pipeline := loadTestPipeline(t, `
+name: env
+jobs:
+  release:
+    env:
+      vars:
+        TAG: ${{ args[0] }}
+    steps:
+      - run: "true"
+`)
+
+	env, err := runner.JobEnv(t.Context(), pipeline, "release", runner.PipelineOptions{Args: []string{"v1.2.3"}})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", env["TAG"])
+}
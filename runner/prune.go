@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStateDir holds local state like the change state. It is
+// relative to the working directory.
+const DefaultStateDir = ".atkins"
+
+// ParseMaxAge parses an age like `7d`, `12h` or `90m`. Days are
+// supported in addition to the units of time.ParseDuration.
+func ParseMaxAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return age, nil
+}
+
+// PruneStateDir removes files under dir that were last modified before
+// cutoff, and then the empty directories below dir. It returns the
+// removed paths. A missing dir is not an error.
+func PruneStateDir(dir string, cutoff time.Time) ([]string, error) {
+	var removed, dirs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed = append(removed, path)
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	// Remove emptied directories, deepest first
+	slices.Reverse(dirs)
+	for _, path := range dirs {
+		if entries, err := os.ReadDir(path); err == nil && len(entries) == 0 {
+			if err := os.Remove(path); err == nil {
+				removed = append(removed, path)
+			}
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,60 @@
+package runner_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestPruneStateDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".atkins")
+	old := time.Now().Add(-10 * 24 * time.Hour)
+
+	write := func(name string, modified time.Time) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+		require.NoError(t, os.Chtimes(path, modified, modified))
+		return path
+	}
+	oldLog := write("logs/old.yml", old)
+	newLog := write("logs/new.yml", time.Now())
+	oldCache := write("cache/old.bin", old)
+	state := write("state", time.Now())
+
+	removed, err := runner.PruneStateDir(dir, time.Now().Add(-7*24*time.Hour))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{oldLog, oldCache, filepath.Join(dir, "cache")}, removed)
+
+	assert.NoFileExists(t, oldLog)
+	assert.NoFileExists(t, oldCache)
+	assert.NoDirExists(t, filepath.Join(dir, "cache"))
+	assert.FileExists(t, newLog)
+	assert.FileExists(t, state)
+
+	// A missing state dir is not an error
+	removed, err = runner.PruneStateDir(filepath.Join(t.TempDir(), "missing"), time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+}
+
+func TestParseMaxAge(t *testing.T) {
+	age, err := runner.ParseMaxAge("7d")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, age)
+
+	age, err = runner.ParseMaxAge("90m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, age)
+
+	for _, invalid := range []string{"", "d", "-1d", "week", "-5h"} {
+		_, err := runner.ParseMaxAge(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactMask replaces text matched by redact patterns.
+const RedactMask = "***"
+
+// Redactor replaces matches of patterns in output and logs with RedactMask.
+type Redactor struct {
+	pattern *regexp.Regexp
+}
+
+// NewRedactor compiles patterns into a single expression, so output is
+// scanned once regardless of the pattern count. Returns nil without patterns.
+func NewRedactor(patterns []string) (*Redactor, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	alternates := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		alternates = append(alternates, "(?:"+pattern+")")
+	}
+
+	return &Redactor{
+		pattern: regexp.MustCompile(strings.Join(alternates, "|")),
+	}, nil
+}
+
+// Redact returns s with matches replaced. A nil Redactor returns s unchanged.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	return r.pattern.ReplaceAllLiteralString(s, RedactMask)
+}
+
+// RedactLines redacts each line in place and returns lines.
+func (r *Redactor) RedactLines(lines []string) []string {
+	if r == nil {
+		return lines
+	}
+	for i, line := range lines {
+		lines[i] = r.Redact(line)
+	}
+	return lines
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// retryDelay returns the delay before the given retry (1-based) of a job.
+// The configured retry_delay is doubled for each subsequent retry.
+func retryDelay(job *model.Job, retry int) time.Duration {
+	delay := parseTimeout(job.RetryDelay, 0)
+	for i := 1; i < retry; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+// sleepContext waits for the duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
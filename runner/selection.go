@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StepSelection is a set of step indices selected to run.
+// A nil selection selects every step.
+type StepSelection map[int]bool
+
+// ParseStepSelection parses a comma separated list of step indices
+// and ranges, e.g. `1,3-5`. Indices are zero based, matching step IDs.
+func ParseStepSelection(spec string) (StepSelection, error) {
+	selection := make(StepSelection)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid step index %q", part)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(to))
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid step range %q", part)
+			}
+		}
+
+		for idx := start; idx <= end; idx++ {
+			selection[idx] = true
+		}
+	}
+
+	if len(selection) == 0 {
+		return nil, fmt.Errorf("empty step selection %q", spec)
+	}
+	return selection, nil
+}
+
+// Contains returns true if the step at idx is selected.
+func (s StepSelection) Contains(idx int) bool {
+	return s == nil || s[idx]
+}
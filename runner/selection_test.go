@@ -0,0 +1,24 @@
+package runner_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titpetric/atkins/runner"
+)
+
+func TestParseStepSelection(t *testing.T) {
+	selection, err := runner.ParseStepSelection("1, 3-5")
+	assert.NoError(t, err)
+	assert.Equal(t, runner.StepSelection{1: true, 3: true, 4: true, 5: true}, selection)
+
+	assert.True(t, selection.Contains(4))
+	assert.False(t, selection.Contains(2))
+	assert.True(t, runner.StepSelection(nil).Contains(2))
+
+	for _, spec := range []string{"", "a", "-1", "5-3", "1-b"} {
+		_, err := runner.ParseStepSelection(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/titpetric/atkins/colors"
+	"github.com/titpetric/atkins/treeview"
+)
+
+// Skip is a node that didn't run and why.
+type Skip struct {
+	ID     string // ID of the node, or of the nearest parent with one
+	Name   string
+	Reason string
+}
+
+// CollectSkips walks the tree for skipped nodes, in tree order. The
+// children of a skipped node are left out, they're skipped with it.
+func CollectSkips(root *treeview.Node) []Skip {
+	var skips []Skip
+	var walk func(node *treeview.Node, id string)
+	walk = func(node *treeview.Node, id string) {
+		// Steps that didn't run have no ID of their own
+		if node.ID != "" {
+			id = node.ID
+		}
+		if node.Status == treeview.StatusSkipped {
+			reason := node.GetSkipReason()
+			if reason == "" {
+				reason = "no reason recorded"
+			}
+			skips = append(skips, Skip{ID: id, Name: node.Name, Reason: reason})
+			return
+		}
+		for _, child := range node.GetChildren() {
+			walk(child, id)
+		}
+	}
+	walk(root, "")
+
+	return skips
+}
+
+// WriteSkips prints skips in a Skipped section, one per line.
+func WriteSkips(w io.Writer, skips []Skip) {
+	if len(skips) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\n", colors.BrightYellow("Skipped:"))
+	for _, skip := range skips {
+		fmt.Fprintf(w, "  %s %s %s\n", colors.BrightYellow(skip.ID), skip.Name, colors.Gray("("+skip.Reason+")"))
+	}
+}
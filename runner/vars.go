@@ -2,19 +2,23 @@ package runner
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 )
 
-// extractVariableDependencies extracts variable names referenced via ${{ varName }} in a string.
-// Only returns dependencies that exist in the vars map.
-func extractVariableDependencies(s string, vars map[string]any) []string {
+// extractVariableDependencies extracts variable names referenced via ${{ varName }}
+// in the value s of variable name. Only returns dependencies that exist in the vars
+// map. A self reference is not a dependency, it resolves to the inherited value,
+// e.g. `PATH: "${{ PATH }}:/custom"`.
+func extractVariableDependencies(name, s string, vars map[string]any) []string {
 	matches := interpolationRegex.FindAllStringSubmatch(s, -1)
 	var deps []string
 	seen := make(map[string]bool)
 	for _, match := range matches {
 		if len(match) > 1 {
 			varName := strings.TrimSpace(match[1])
-			if _, exists := vars[varName]; exists && !seen[varName] {
+			if _, exists := vars[varName]; exists && !seen[varName] && varName != name {
 				deps = append(deps, varName)
 				seen[varName] = true
 			}
@@ -48,7 +52,8 @@ func topologicalSort(deps map[string][]string) ([]string, error) {
 		return nil
 	}
 
-	for node := range deps {
+	// Visit in sorted order so evaluation order is stable between runs
+	for _, node := range slices.Sorted(maps.Keys(deps)) {
 		if visited[node] == 0 {
 			if err := visit(node); err != nil {
 				return nil, err
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/titpetric/atkins/model"
+)
+
+// createWorkspace prepares an isolated working directory for a job.
+// The project in src is either copied or its top-level entries are
+// symlinked into a new temp dir. The returned cleanup removes the dir.
+func createWorkspace(mode, src, name string) (string, func(), error) {
+	if src == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", nil, err
+		}
+		src = wd
+	}
+
+	dir, err := os.MkdirTemp("", "atkins-"+sanitizeWorkspaceName(name)+"-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(dir)
+	}
+
+	switch mode {
+	case model.WorkspaceCopy:
+		err = copyTree(src, dir)
+	case model.WorkspaceSymlink:
+		err = symlinkEntries(src, dir)
+	default:
+		err = fmt.Errorf("unknown workspace mode %q (expected %q or %q)", mode, model.WorkspaceCopy, model.WorkspaceSymlink)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// sanitizeWorkspaceName makes a job name usable as a temp dir prefix.
+func sanitizeWorkspaceName(name string) string {
+	out := []rune(name)
+	for i, r := range out {
+		if r == '/' || r == ':' || r == ' ' || r == filepath.Separator {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+// symlinkEntries links every top-level entry of src into dst.
+func symlinkEntries(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Symlink(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTree recursively copies src into dst, preserving file modes and symlinks.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// copyFile copies a single regular file.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -7,9 +7,10 @@ import (
 	"fmt"
 	"os"
 	"regexp"
-	"strings"
 
 	yaml "gopkg.in/yaml.v3"
+
+	"github.com/titpetric/atkins/runner"
 )
 
 type Result string
@@ -31,19 +32,6 @@ type Log struct {
 	State    *StateNode  `yaml:"state"`
 }
 
-type Entity struct {
-	ID        string
-	Label     string
-	Type      string // "pipeline", "job", "step", "task"
-	ExecCount int
-}
-
-type Relation struct {
-	From  string
-	To    string
-	Label string
-}
-
 // StepResult tracks IDs for multi-link chaining
 type StepResult struct {
 	StepID     string // The step/task itself
@@ -52,8 +40,7 @@ type StepResult struct {
 }
 
 var (
-	entities  = make(map[string]*Entity)
-	relations []Relation
+	graph     = &runner.Graph{}
 	taskRegex = regexp.MustCompile(`^task:\s*(.+)$`)
 	runRegex  = regexp.MustCompile(`^run:\s*(.+)$`)
 )
@@ -80,12 +67,9 @@ func main() {
 	}
 
 	// Process the tree
-	pipelineID := sanitizeID(log.State.Name)
-	entities[pipelineID] = &Entity{
-		ID:    pipelineID,
-		Label: log.State.Name,
-		Type:  "pipeline",
-	}
+	graph.Name = log.Metadata.Pipeline
+	pipelineID := log.State.Name
+	graph.AddNode(pipelineID, log.State.Name, runner.GraphPipeline)
 
 	// Process pipeline children (jobs)
 	var prevJobID string
@@ -95,24 +79,23 @@ func main() {
 	}
 
 	// Output PlantUML
-	outputPlantUML(log.Metadata.Pipeline)
+	if err := graph.WritePlantUML(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing PlantUML: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func processJob(pipelineID string, node *StateNode, prevJobID string) string {
-	jobID := sanitizeID(node.Name)
+	jobID := node.Name
 
-	entities[jobID] = &Entity{
-		ID:    jobID,
-		Label: node.Name,
-		Type:  "job",
-	}
+	graph.AddNode(jobID, node.Name, runner.GraphJob)
 
 	// Pipeline -> Job
-	relations = append(relations, Relation{From: pipelineID, To: jobID, Label: "job"})
+	graph.AddEdge(pipelineID, jobID, "job")
 
 	// Chain jobs: prevJob -> thisJob
 	if prevJobID != "" {
-		relations = append(relations, Relation{From: prevJobID, To: jobID, Label: "next"})
+		graph.AddEdge(prevJobID, jobID, "next")
 	}
 
 	// Process children (steps or tasks)
@@ -145,17 +128,17 @@ func processStep(parentID string, node *StateNode, index int, prev *StepResult)
 	// Helper to add links from previous step/task/job to current
 	addPrevLinks := func(toID string) {
 		if prev == nil {
-			relations = append(relations, Relation{From: parentID, To: toID, Label: "step.0"})
+			graph.AddEdge(parentID, toID, "step.0")
 		} else {
 			// Link from previous task
-			relations = append(relations, Relation{From: prev.StepID, To: toID, Label: fmt.Sprintf("step.%d", index)})
+			graph.AddEdge(prev.StepID, toID, fmt.Sprintf("step.%d", index))
 			// Link from invoked job (if any)
 			if prev.InvokedJob != "" {
-				relations = append(relations, Relation{From: prev.InvokedJob, To: toID, Label: "next"})
+				graph.AddEdge(prev.InvokedJob, toID, "next")
 			}
 			// Link from exit point (last step of invoked job)
 			if prev.ExitID != prev.StepID && prev.ExitID != prev.InvokedJob {
-				relations = append(relations, Relation{From: prev.ExitID, To: toID, Label: "completes"})
+				graph.AddEdge(prev.ExitID, toID, "completes")
 			}
 		}
 	}
@@ -163,13 +146,9 @@ func processStep(parentID string, node *StateNode, index int, prev *StepResult)
 	// Check if this is a task invocation
 	if matches := taskRegex.FindStringSubmatch(name); len(matches) > 1 {
 		taskName := matches[1]
-		taskID := fmt.Sprintf("%s_task_%s", parentID, sanitizeID(taskName))
+		taskID := fmt.Sprintf("%s_task_%s", parentID, taskName)
 
-		entities[taskID] = &Entity{
-			ID:    taskID,
-			Label: fmt.Sprintf("task: %s", taskName),
-			Type:  "task",
-		}
+		graph.AddNode(taskID, fmt.Sprintf("task: %s", taskName), runner.GraphTask)
 
 		addPrevLinks(taskID)
 
@@ -178,15 +157,11 @@ func processStep(parentID string, node *StateNode, index int, prev *StepResult)
 		if len(node.Children) > 0 {
 			for _, taskChild := range node.Children {
 				// This is the invoked job
-				invokedJobID := sanitizeID(taskChild.Name)
-				if _, exists := entities[invokedJobID]; !exists {
-					entities[invokedJobID] = &Entity{
-						ID:    invokedJobID,
-						Label: taskChild.Name,
-						Type:  "job",
-					}
+				invokedJobID := taskChild.Name
+				if graph.Node(invokedJobID) == nil {
+					graph.AddNode(invokedJobID, taskChild.Name, runner.GraphJob)
 				}
-				relations = append(relations, Relation{From: taskID, To: invokedJobID, Label: "invokes"})
+				graph.AddEdge(taskID, invokedJobID, "invokes")
 				result.InvokedJob = invokedJobID
 
 				// Process the invoked job's steps and get exit point
@@ -215,12 +190,7 @@ func processStep(parentID string, node *StateNode, index int, prev *StepResult)
 			label = fmt.Sprintf("%s (%d execs)", label, execCount)
 		}
 
-		entities[stepID] = &Entity{
-			ID:        stepID,
-			Label:     label,
-			Type:      "step",
-			ExecCount: execCount,
-		}
+		graph.AddNode(stepID, label, runner.GraphStep)
 
 		addPrevLinks(stepID)
 		return &StepResult{StepID: stepID, ExitID: stepID}
@@ -239,12 +209,7 @@ func processStep(parentID string, node *StateNode, index int, prev *StepResult)
 			label = fmt.Sprintf("%s (%d execs)", label, execCount)
 		}
 
-		entities[stepID] = &Entity{
-			ID:        stepID,
-			Label:     label,
-			Type:      "step",
-			ExecCount: execCount,
-		}
+		graph.AddNode(stepID, label, runner.GraphStep)
 
 		addPrevLinks(stepID)
 		return &StepResult{StepID: stepID, ExitID: stepID}
@@ -269,71 +234,6 @@ func countLeafExecs(node *StateNode) int {
 	return count
 }
 
-func outputPlantUML(title string) {
-	fmt.Println("@startuml")
-	fmt.Printf("title %s\n", title)
-	fmt.Println()
-	fmt.Println("skinparam componentStyle rectangle")
-	fmt.Println("skinparam defaultTextAlignment center")
-	fmt.Println("top to bottom direction")
-	fmt.Println()
-
-	// Group by type using components
-	for _, e := range entities {
-		switch e.Type {
-		case "pipeline":
-			fmt.Printf("component \"%s\" as %s #LightBlue\n", e.Label, e.ID)
-		case "job":
-			fmt.Printf("component \"%s\" as %s #LightGreen\n", e.Label, e.ID)
-		case "task":
-			fmt.Printf("component \"%s\" as %s #Khaki\n", e.Label, e.ID)
-		case "step":
-			fmt.Printf("component \"%s\" as %s #WhiteSmoke\n", e.Label, e.ID)
-		}
-	}
-
-	fmt.Println()
-	fmt.Println("' Relationships")
-
-	for _, r := range relations {
-		switch r.Label {
-		case "invokes":
-			fmt.Printf("%s ..> %s\n", r.From, r.To)
-		case "next":
-			fmt.Printf("%s --> %s\n", r.From, r.To)
-		case "job":
-			fmt.Printf("%s --> %s\n", r.From, r.To)
-		default:
-			fmt.Printf("%s --> %s\n", r.From, r.To)
-		}
-	}
-
-	fmt.Println()
-	fmt.Println("@enduml")
-}
-
-func sanitizeID(s string) string {
-	s = strings.ReplaceAll(s, " ", "_")
-	s = strings.ReplaceAll(s, ":", "_")
-	s = strings.ReplaceAll(s, "-", "_")
-	s = strings.ReplaceAll(s, ".", "_")
-	s = strings.ReplaceAll(s, "/", "_")
-	s = strings.ReplaceAll(s, "(", "")
-	s = strings.ReplaceAll(s, ")", "")
-	s = strings.ReplaceAll(s, "'", "")
-	s = strings.ReplaceAll(s, "\"", "")
-	s = strings.ReplaceAll(s, "$", "")
-	s = strings.ReplaceAll(s, "{", "")
-	s = strings.ReplaceAll(s, "}", "")
-	s = strings.ReplaceAll(s, ",", "_")
-	s = strings.ReplaceAll(s, "=", "_")
-	// Limit length
-	if len(s) > 50 {
-		s = s[:50]
-	}
-	return s
-}
-
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -2,6 +2,7 @@ package treeview
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/titpetric/atkins/colors"
 	"github.com/titpetric/atkins/model"
@@ -10,6 +11,12 @@ import (
 // Builder constructs tree nodes from pipeline data.
 type Builder struct {
 	root *Node
+
+	// showCommands expands step commands into child nodes.
+	showCommands bool
+
+	// showStepDesc sets step descriptions on step nodes.
+	showStepDesc bool
 }
 
 // NewBuilder creates a new tree builder.
@@ -26,9 +33,18 @@ func (b *Builder) Root() *Node {
 
 // AddJob adds a job node to the tree with all its steps.
 func (b *Builder) AddJob(job *model.Job, deps []string, jobName string) *TreeNode {
+	jobNode := b.BuildJob(job, jobName)
+	jobNode.Dependencies = deps
+
+	b.root.AddChild(jobNode.Node)
+
+	return jobNode
+}
+
+// BuildJob builds a job node with all its steps, without adding it to the tree.
+func (b *Builder) BuildJob(job *model.Job, jobName string) *TreeNode {
 	// Create job node
 	jobNode := NewJobNode(jobName, job.Nested)
-	jobNode.Dependencies = deps
 	jobNode.Summarize = job.Summarize
 
 	// Add steps as children (skip for simple single-step tasks where command is in job name)
@@ -42,8 +58,6 @@ func (b *Builder) AddJob(job *model.Job, deps []string, jobName string) *TreeNod
 		}
 	}
 
-	b.root.AddChild(jobNode)
-
 	return &TreeNode{
 		Node: jobNode,
 	}
@@ -99,19 +113,77 @@ func (b *Builder) buildStepNode(step *model.Step) *Node {
 	stepNode := NewNode(stepName)
 	stepNode.Summarize = step.Summarize
 	stepNode.Deferred = step.Deferred
+	if b.showStepDesc {
+		stepNode.Desc = step.Desc
+	}
+
+	if b.showCommands {
+		for _, cmd := range step.Commands() {
+			if len(step.Cmds) == 0 && !strings.Contains(cmd, "\n") {
+				stepNode.Command = true
+				continue // Already shown in full as the step label
+			}
+			for _, line := range strings.Split(strings.TrimRight(cmd, "\n"), "\n") {
+				cmdNode := NewCmdNode(line)
+				cmdNode.Command = true
+				stepNode.AddChild(cmdNode)
+			}
+		}
+	}
 
 	return stepNode
 }
 
+// Job kinds used to label jobs when listing.
+const (
+	JobKindJob    = "job"
+	JobKindTask   = "task"
+	JobKindNested = "nested"
+)
+
+// JobKind classifies a job definition for listing. Root jobs are invokable
+// directly, tasks are root-level but hidden helpers, and nested jobs have
+// a ':' in their name and are usually only invoked via `task:`.
+func JobKind(name string, job *model.Job) string {
+	if job.Nested || strings.Contains(name, ":") {
+		return JobKindNested
+	}
+	if job.Show != nil && !*job.Show {
+		return JobKindTask
+	}
+	return JobKindJob
+}
+
+// BuildOptions controls what BuildFromPipelineWithOptions includes in the tree.
+type BuildOptions struct {
+	Kinds    bool // Prefix each job with its kind, e.g. [job], [task] or [nested]
+	Commands bool // Expand cmds and multi-line scripts into full command lines
+
+	// DescLines renders job descriptions on a line below the job,
+	// instead of appending them to the job name.
+	DescLines bool
+
+	// Verbose renders step descriptions on a line below each step.
+	Verbose bool
+}
+
 // BuildFromPipeline constructs a complete tree from a pipeline.
 // Returns the root node ready to be rendered.
 func BuildFromPipeline(pipeline *model.Pipeline, resolveDeps func(map[string]*model.Job, string) ([]string, error)) (*Node, error) {
+	return BuildFromPipelineWithOptions(pipeline, resolveDeps, BuildOptions{})
+}
+
+// BuildFromPipelineWithOptions constructs a tree like BuildFromPipeline, with
+// additional detail enabled by opts.
+func BuildFromPipelineWithOptions(pipeline *model.Pipeline, resolveDeps func(map[string]*model.Job, string) ([]string, error), opts BuildOptions) (*Node, error) {
 	jobs := pipeline.Jobs
 	if len(jobs) == 0 {
 		jobs = pipeline.Tasks
 	}
 
 	builder := NewBuilder(pipeline.Name)
+	builder.showCommands = opts.Commands
+	builder.showStepDesc = opts.Verbose
 
 	// Get jobs in dependency order
 	jobOrder, err := resolveDeps(jobs, "")
@@ -137,11 +209,21 @@ func BuildFromPipeline(pipeline *model.Pipeline, resolveDeps func(map[string]*mo
 		job := jobs[jobName]
 		// Build job label with optional description
 		jobLabel := jobName
-		if job.Desc != "" {
+		if job.Desc != "" && !opts.DescLines {
 			jobLabel = jobName + " - " + job.Desc
 		}
+		if opts.Kinds {
+			jobLabel = "[" + JobKind(jobName, job) + "] " + jobLabel
+		}
 
 		jobNode := builder.AddJob(job, job.DependsOn, jobLabel)
+		jobNode.Node.ID = "jobs." + jobName
+		if opts.DescLines {
+			jobNode.Node.Desc = job.Desc
+		}
+		for idx, stepNode := range jobNode.Node.GetChildren() {
+			stepNode.ID = fmt.Sprintf("%s.steps.%d", jobNode.Node.ID, idx)
+		}
 
 		// Mark jobs that won't be executed
 		if !willRun[jobName] {
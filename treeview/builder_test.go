@@ -1,10 +1,12 @@
 package treeview
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/titpetric/atkins/colors"
 	"github.com/titpetric/atkins/model"
 )
 
@@ -409,6 +411,38 @@ func TestBuildFromPipeline_WithSummarize(t *testing.T) {
 	})
 }
 
+// TestBuildFromPipelineWithOptions_Kinds tests labeling root, hidden and nested jobs
+func TestBuildFromPipelineWithOptions_Kinds(t *testing.T) {
+	hidden := false
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"build":        {Desc: "Build it"},
+			"setup":        {Show: &hidden},
+			"build:docker": {Nested: true},
+		},
+	}
+
+	node, err := BuildFromPipelineWithOptions(pipeline, mockResolveDeps, BuildOptions{Kinds: true})
+	assert.NoError(t, err)
+
+	var names []string
+	for _, child := range node.GetChildren() {
+		names = append(names, child.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"[job] build - Build it",
+		"[task] setup",
+		"[nested] build:docker",
+	}, names)
+
+	node, err = BuildFromPipeline(pipeline, mockResolveDeps)
+	assert.NoError(t, err)
+	for _, child := range node.GetChildren() {
+		assert.NotContains(t, child.Name, "[")
+	}
+}
+
 // mockResolveDeps is a mock function for resolving dependencies
 func mockResolveDeps(jobs map[string]*model.Job, startingJob string) ([]string, error) {
 	result := make([]string, 0, len(jobs))
@@ -417,3 +451,75 @@ func mockResolveDeps(jobs map[string]*model.Job, startingJob string) ([]string,
 	}
 	return result, nil
 }
+
+// TestBuildFromPipelineWithOptions_Commands tests expanding cmds and multi-line scripts
+func TestBuildFromPipelineWithOptions_Commands(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"build": {
+				Steps: []*model.Step{
+					{Cmds: []string{"go vet ./...", "go build ./..."}},
+					{Run: "echo one\necho two\n"},
+					{Run: "go test ./..."},
+					{Cmds: []string{"docker build --build-arg=VERSION=v1.2.3-rc.1+build.42 ."}},
+					{Run: "go test -run=TestRunPipeline_OnlyFailedOutput ./runner"},
+				},
+			},
+		},
+	}
+
+	node, err := BuildFromPipelineWithOptions(pipeline, mockResolveDeps, BuildOptions{Commands: true})
+	assert.NoError(t, err)
+
+	var lines []string
+	var walk func(*Node)
+	walk = func(n *Node) {
+		lines = append(lines, n.Name)
+		for _, child := range n.GetChildren() {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	for _, cmd := range []string{"go vet ./...", "go build ./...", "echo one", "echo two"} {
+		assert.Contains(t, lines, cmd)
+	}
+	assert.Contains(t, lines, "run: go test ./...")
+	assert.NotContains(t, lines, "go test ./...")
+
+	// Long arguments of commands aren't compacted
+	output := colors.StripANSI(NewRenderer().RenderStatic(node))
+	assert.Contains(t, output, "docker build --build-arg=VERSION=v1.2.3-rc.1+build.42 .\n")
+	assert.Contains(t, output, "run: go test -run=TestRunPipeline_OnlyFailedOutput ./runner\n")
+	assert.NotContains(t, output, "chars>")
+}
+
+func TestBuildFromPipelineWithOptions_Verbose(t *testing.T) {
+	pipeline := &model.Pipeline{
+		Name: "test-pipeline",
+		Jobs: map[string]*model.Job{
+			"build": {
+				Steps: []*model.Step{
+					{Run: "go build ./...", Desc: "Build all packages"},
+					{Run: "go test ./..."},
+				},
+			},
+		},
+	}
+
+	r := NewRenderer()
+	r.SetTreeStyle(ASCIIStyle)
+
+	node, err := BuildFromPipelineWithOptions(pipeline, mockResolveDeps, BuildOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, colors.StripANSI(r.RenderStatic(node)), "Build all packages")
+
+	node, err = BuildFromPipelineWithOptions(pipeline, mockResolveDeps, BuildOptions{Verbose: true})
+	assert.NoError(t, err)
+
+	lines := strings.Split(colors.StripANSI(r.RenderStatic(node)), "\n")
+	assert.Contains(t, lines[2], "run: go build ./...")
+	assert.Equal(t, "   |  Build all packages", lines[3])
+	assert.Contains(t, lines[4], "run: go test ./...")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"golang.org/x/term"
 )
@@ -15,9 +16,18 @@ type Display struct {
 	isTerminal    bool
 	renderer      *Renderer
 	finalOnly     bool
+
+	// hidden disables rendering, e.g. when stdout carries events.
+	hidden bool
+
+	// An animated spinner redraws root every interval while it runs.
+	spinner Spinner
+	root    *Node
+	stop    chan struct{}
 }
 
 // NewDisplay creates a new display manager.
+// In-place updates need a terminal, independent of the color mode.
 func NewDisplay() *Display {
 	isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
 	return &Display{
@@ -44,16 +54,123 @@ func (d *Display) IsTerminal() bool {
 	return d.isTerminal
 }
 
+// SetTreeStyle sets the characters used to draw the tree.
+func (d *Display) SetTreeStyle(style TreeStyle) {
+	d.renderer.SetTreeStyle(style)
+}
+
+// SetShowIDs enables showing node IDs next to each rendered line.
+func (d *Display) SetShowIDs(show bool) {
+	d.renderer.SetShowIDs(show)
+}
+
+// SetShowAllOutput shows captured output of passed nodes, which is folded by default.
+func (d *Display) SetShowAllOutput(show bool) {
+	d.renderer.SetShowAllOutput(show)
+}
+
+// SetCollapse collapses runs of passed or skipped leaf nodes into a single line.
+func (d *Display) SetCollapse(collapse bool) {
+	d.renderer.SetCollapse(collapse)
+}
+
+// SetRedact sets a function applied to the rendered tree, e.g. to scrub secrets.
+func (d *Display) SetRedact(redact func(string) string) {
+	d.renderer.SetRedact(redact)
+}
+
+// SetSpinner sets the frames shown as the status of running nodes.
+// A zero interval uses the default interval of the spinner.
+func (d *Display) SetSpinner(spinner Spinner, interval time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if interval > 0 {
+		spinner.Interval = interval
+	}
+	d.spinner = spinner
+	d.renderer.SetSpinner(spinner)
+}
+
+// Stop ends the spinner animation, later renders don't restart it.
+func (d *Display) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.spinner = NoSpinner
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+}
+
+// animate redraws the tree with the next spinner frame every interval,
+// while a node is running and the tree hasn't finished.
+func (d *Display) animate(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		d.mu.Lock()
+		if d.stop == stop && d.root != nil && isRunning(d.root) {
+			d.renderer.NextFrame()
+			d.redraw(d.root)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// isRunning returns true if root hasn't finished and has a running node.
+func isRunning(root *Node) bool {
+	if root.Status == StatusPassed || root.Status == StatusFailed {
+		return false
+	}
+	var running func(node *Node) bool
+	running = func(node *Node) bool {
+		if node.Status == StatusRunning {
+			return true
+		}
+		for _, child := range node.GetChildren() {
+			if running(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return running(root)
+}
+
+// SetHidden disables all rendering of the tree.
+func (d *Display) SetHidden(hidden bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hidden = hidden
+}
+
 // Render outputs the tree, updating in-place if previously rendered.
 func (d *Display) Render(root *Node) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
 	// Only render if stdout is a TTY (interactive terminal)
-	if !d.isTerminal {
+	if !d.isTerminal || d.hidden {
 		return
 	}
 
+	d.root = root
+	if d.spinner.Animated() && d.stop == nil {
+		d.stop = make(chan struct{})
+		go d.animate(d.stop, d.spinner.Interval)
+	}
+	d.redraw(root)
+}
+
+// redraw replaces the previously rendered tree with root.
+func (d *Display) redraw(root *Node) {
 	if d.lastLineCount > 0 {
 		// Move cursor up, clear to end of display
 		fmt.Printf("\033[%dA\033[J", d.lastLineCount)
@@ -70,6 +187,10 @@ func (d *Display) RenderStatic(root *Node) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if d.hidden {
+		return
+	}
+
 	output := d.renderer.RenderStatic(root)
 	fmt.Print(output)
 }
@@ -10,18 +10,23 @@ import (
 // Node represents a node in the tree (job, step, or iteration).
 type Node struct {
 	Name         string
-	ID           string // Unique identifier (e.g., "job.steps.0", "job.steps.1" for iterations)
+	ID           string // Unique identifier (e.g., "jobs.build.steps.0", "jobs.build.steps.1.iter.0" for iterations)
 	Status       Status
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	StartOffset  float64 // Seconds offset from run start
 	Duration     float64 // Duration in seconds
 	If           string  // Condition that was evaluated (for conditional steps)
+	SkipReason   string  // Why a skipped node didn't run, if not due to its if condition
 	Children     []*Node
 	Dependencies []string
 	Deferred     bool
 	Summarize    bool
 	Output       []string // Multi-line output from command execution
+	Stats        string   // Line rendered below a summarized node, e.g. loop durations
+	Desc         string   // Description rendered as a dimmed line below the node
+	Command      bool     // Name is full command text, rendered without compaction or trimming
+	KeepOutput   bool     // Output is shown even if the node passed, e.g. the lines after an as_label label
 	mu           sync.Mutex
 }
 
@@ -127,6 +132,21 @@ func (n *Node) SetStatus(status Status) {
 	n.UpdatedAt = time.Now()
 }
 
+// FailRunning marks the node and all of its running descendants as failed.
+// It's used to settle the tree when a run is cancelled mid-flight.
+func (n *Node) FailRunning() {
+	for _, child := range n.GetChildren() {
+		child.FailRunning()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.Status == StatusRunning {
+		n.Status = StatusFailed
+		n.UpdatedAt = time.Now()
+	}
+}
+
 // SetStartOffset sets the start offset from run start.
 func (n *Node) SetStartOffset(offset float64) {
 	n.mu.Lock()
@@ -149,6 +169,34 @@ func (n *Node) SetIf(condition string) {
 	n.If = condition
 }
 
+// SetStats sets the stats line shown below a summarized node.
+func (n *Node) SetStats(stats string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.Stats = stats
+}
+
+// SetSkipReason sets why a skipped node didn't run.
+func (n *Node) SetSkipReason(reason string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.SkipReason = reason
+}
+
+// GetSkipReason returns why a skipped node didn't run, e.g. `if false`,
+// or an empty string if the node isn't skipped or no reason is known.
+func (n *Node) GetSkipReason() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.Status != StatusSkipped {
+		return ""
+	}
+	if n.SkipReason == "" && n.If != "" {
+		return "if " + n.If
+	}
+	return n.SkipReason
+}
+
 // SetOutput sets the output lines for this node (from command execution).
 func (n *Node) SetOutput(lines []string) {
 	n.mu.Lock()
@@ -342,3 +342,16 @@ func TestNodeNestedStructure(t *testing.T) {
 		}
 	})
 }
+
+// TestNodeGetSkipReason tests the skip reason with the if condition fallback
+func TestNodeGetSkipReason(t *testing.T) {
+	node := NewNode("step")
+	node.SetIf("false")
+	assert.Equal(t, "", node.GetSkipReason())
+
+	node.SetStatus(StatusSkipped)
+	assert.Equal(t, "if false", node.GetSkipReason())
+
+	node.SetSkipReason("unchanged")
+	assert.Equal(t, "unchanged", node.GetSkipReason())
+}
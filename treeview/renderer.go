@@ -16,6 +16,21 @@ type Renderer struct {
 	mu        sync.Mutex
 	trimmer   *Trimmer
 	maxArgLen int
+	style     TreeStyle
+	showIDs   bool
+
+	// showAllOutput disables folding the output of passed nodes.
+	showAllOutput bool
+
+	// collapse groups consecutive passed or skipped leaf nodes into one line.
+	collapse bool
+
+	// spinner frames replace the status of running nodes, frame is the current one.
+	spinner Spinner
+	frame   int
+
+	// redact scrubs the rendered tree, e.g. secrets in step labels.
+	redact func(string) string
 }
 
 // NewRenderer creates a new tree renderer.
@@ -23,12 +38,91 @@ func NewRenderer() *Renderer {
 	return &Renderer{
 		trimmer:   NewTrimmer(),
 		maxArgLen: DefaultMaxArgLen,
+		style:     DefaultTreeStyle(),
 	}
 }
 
-// trimLabel applies argument compaction and viewport trimming to a label.
-func (r *Renderer) trimLabel(label string, prefixLen int) string {
-	if r.trimmer == nil {
+// SetTreeStyle sets the characters used to draw the tree.
+func (r *Renderer) SetTreeStyle(style TreeStyle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.style = style
+}
+
+// SetShowIDs enables appending node IDs to rendered lines, e.g. `jobs.build.steps.2`.
+func (r *Renderer) SetShowIDs(show bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.showIDs = show
+}
+
+// SetShowAllOutput shows captured output of all nodes. By default,
+// output is only shown for nodes that didn't pass.
+func (r *Renderer) SetShowAllOutput(show bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.showAllOutput = show
+}
+
+// SetCollapse collapses runs of consecutive leaf nodes that passed
+// or were skipped, without visible output, into a line like `✓ x47`.
+func (r *Renderer) SetCollapse(collapse bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collapse = collapse
+}
+
+// SetRedact sets a function applied to the rendered tree.
+func (r *Renderer) SetRedact(redact func(string) string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redact = redact
+}
+
+// redactOutput applies the redact function to output, if set.
+func (r *Renderer) redactOutput(output string) string {
+	if r.redact == nil {
+		return output
+	}
+	return r.redact(output)
+}
+
+// SetSpinner sets the frames shown as the status of running nodes.
+func (r *Renderer) SetSpinner(spinner Spinner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spinner = spinner
+	r.frame = 0
+}
+
+// NextFrame advances the spinner to its next frame.
+func (r *Renderer) NextFrame() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frame++
+}
+
+// statusColor returns the status indicator of node, the current
+// spinner frame if it's running.
+func (r *Renderer) statusColor(node *Node) string {
+	if node.Status == StatusRunning && r.spinner.Animated() {
+		return colors.BrightOrange(r.spinner.Frames[r.frame%len(r.spinner.Frames)])
+	}
+	return node.StatusColor()
+}
+
+// withID appends the node ID to label if IDs are shown.
+func (r *Renderer) withID(label string, node *Node) string {
+	if !r.showIDs || node.ID == "" {
+		return label
+	}
+	return label + " " + colors.Gray(node.ID)
+}
+
+// trimLabel applies argument compaction and viewport trimming to the
+// label of node. Command labels are kept in full.
+func (r *Renderer) trimLabel(node *Node, label string, prefixLen int) string {
+	if r.trimmer == nil || node.Command {
 		return label
 	}
 	return r.trimmer.TrimLabel(label, r.maxArgLen, prefixLen)
@@ -48,7 +142,7 @@ func (r *Renderer) Render(root *Node) string {
 
 	if root.Summarize {
 		output += r.renderNodeSummary(root, "", true)
-		return output
+		return r.redactOutput(output)
 	}
 
 	children := root.GetChildren()
@@ -57,7 +151,7 @@ func (r *Renderer) Render(root *Node) string {
 		output += r.renderNodeForExecution(child, "", isLast)
 	}
 
-	return output
+	return r.redactOutput(output)
 }
 
 // RenderStatic renders a static tree (for list views) without spinners.
@@ -69,7 +163,7 @@ func (r *Renderer) RenderStatic(root *Node) string {
 
 	if root.Summarize {
 		output += r.renderNodeSummary(root, "", true)
-		return output
+		return r.redactOutput(output)
 	}
 
 	children := root.GetChildren()
@@ -78,16 +172,13 @@ func (r *Renderer) RenderStatic(root *Node) string {
 		output += r.renderStaticNode(child, "", isLast)
 	}
 
-	return output
+	return r.redactOutput(output)
 }
 
 // renderNodeSummary will give a one-liner with status (pending, running, passed...)
 func (r *Renderer) renderNodeSummary(node *Node, prefix string, isLast bool) string {
 	// Determine branch character
-	branch := "├─ "
-	if isLast {
-		branch = "└─ "
-	}
+	branch := r.style.branch(isLast)
 
 	var pending, running, passing, failed int
 	for _, child := range node.GetChildren() {
@@ -109,23 +200,41 @@ func (r *Renderer) renderNodeSummary(node *Node, prefix string, isLast bool) str
 		summary = colors.Green(fmt.Sprintf("%d/%d", passing, total))
 	}
 
+	// Show a progress bar while the summarized node is running
+	if node.Status == StatusRunning && total > 0 {
+		summary = progressBar(passing, total) + " " + summary
+	}
+
 	// Trim label to fit viewport (prefix + branch = indentation)
 	prefixLen := colors.VisualLength(prefix + branch)
 
 	// If no summary items, just show the node name
 	if len(summary) == 0 {
 		label := node.Label()
-		status := node.StatusColor()
+		status := r.statusColor(node)
 		if status != "" {
 			label = label + " " + status
 		}
-		label = r.trimLabel(label, prefixLen)
+		label = r.trimLabel(node, label, prefixLen)
 		return prefix + branch + label + "\n"
 	}
 
-	label := node.Label() + " " + node.StatusColor() + " (" + colors.Gray(summary) + ")"
-	label = r.trimLabel(label, prefixLen)
-	return prefix + branch + label + "\n"
+	label := node.Label() + " " + r.statusColor(node) + " (" + colors.Gray(summary) + ")"
+	label = r.trimLabel(node, label, prefixLen)
+	output := prefix + branch + label + "\n"
+	if node.Stats != "" {
+		output += prefix + r.style.continuation(isLast) + colors.Gray(node.Stats) + "\n"
+	}
+	return output
+}
+
+// progressBarWidth is the number of characters in a progress bar.
+const progressBarWidth = 10
+
+// progressBar renders a plain text bar like `[####------]` for done out of total.
+func progressBar(done, total int) string {
+	filled := done * progressBarWidth / total
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled) + "]"
 }
 
 // renderNodeForExecution renders a node during execution, showing status for all nodes including steps.
@@ -133,17 +242,14 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 	output := ""
 
 	// Determine branch character
-	branch := "├─ "
-	if isLast {
-		branch = "└─ "
-	}
+	branch := r.style.branch(isLast)
 
 	if node.Summarize {
 		return r.renderNodeSummary(node, prefix, isLast)
 	}
 
 	label := node.Label()
-	status := node.StatusColor()
+	status := r.statusColor(node)
 
 	// Build the node label with dependencies and deferred info
 	if len(node.Dependencies) > 0 {
@@ -161,70 +267,37 @@ func (r *Renderer) renderNodeForExecution(node *Node, prefix string, isLast bool
 		!strings.HasSuffix(strings.TrimSpace(label), "✗") {
 		label = label + " " + status
 	}
+	if reason := skipReason(node); reason != "" {
+		label = label + " " + reason
+	}
+	label = r.withID(label, node)
 
 	// Trim label to fit viewport (prefix + branch = indentation)
 	prefixLen := colors.VisualLength(prefix + branch)
-	label = r.trimLabel(label, prefixLen)
+	label = r.trimLabel(node, label, prefixLen)
 
 	// Render this node
 	output += prefix + branch + label
 	output += "\n"
+	output += r.renderDesc(node, prefix, isLast)
 
 	// Render output lines from command execution (with proper indentation)
-	if len(node.Output) > 0 {
-		// Determine continuation character for output indentation
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
-		}
-
-		// Calculate max width of output lines for border (visual width, excluding ANSI)
-		maxWidth := 0
-		for _, outputLine := range node.Output {
-			width := colors.VisualLength(outputLine)
-			if width > maxWidth {
-				maxWidth = width
-			}
-		}
-
-		// Add top border if 2+ elements (account for spaces around content)
-		if len(node.Output) >= 2 {
-			topBorder := prefix + continuation + colors.Gray("┌"+strings.Repeat("─", maxWidth+2)+"┐") + "\n"
-			output += topBorder
-		}
-
-		// Add each output line with left/right borders
-		for _, outputLine := range node.Output {
-			// Pad line to max width for consistent border (using visual width)
-			currentWidth := colors.VisualLength(outputLine)
-			padding := strings.Repeat(" ", maxWidth-currentWidth)
-			paddedLine := " " + outputLine + padding + " "
-			if len(node.Output) >= 2 {
-				output += prefix + continuation + colors.Gray("│") + colors.White(paddedLine) + colors.Gray("│") + "\n"
-			} else {
-				output += prefix + continuation + colors.White(outputLine) + "\n"
-			}
-		}
-
-		// Add bottom border if 2+ elements (account for spaces around content)
-		if len(node.Output) >= 2 {
-			bottomBorder := prefix + continuation + colors.Gray("└"+strings.Repeat("─", maxWidth+2)+"┘") + "\n"
-			output += bottomBorder
-		}
-	}
+	output += r.renderOutput(node, prefix, isLast)
 
 	// Render children
 	children := node.GetChildren()
 	if len(children) > 0 {
 		// Determine continuation character
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
-		}
+		continuation := r.style.continuation(isLast)
 
-		for j, child := range children {
-			childIsLast := j == len(children)-1
-			output += r.renderNodeForExecution(child, prefix+continuation, childIsLast)
+		groups := r.groupChildren(children)
+		for j, group := range groups {
+			childIsLast := j == len(groups)-1
+			if len(group) > 1 {
+				output += r.renderCollapsed(group, prefix+continuation, childIsLast)
+				continue
+			}
+			output += r.renderNodeForExecution(group[0], prefix+continuation, childIsLast)
 		}
 	}
 
@@ -236,10 +309,7 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 	output := ""
 
 	// Determine branch character
-	branch := "├─ "
-	if isLast {
-		branch = "└─ "
-	}
+	branch := r.style.branch(isLast)
 
 	if node.Summarize {
 		return r.renderNodeSummary(node, prefix, isLast)
@@ -259,78 +329,152 @@ func (r *Renderer) renderStaticNode(node *Node, prefix string, isLast bool) stri
 	}
 
 	// Add status indicator only for jobs, not for steps (in list view)
-	isStep := strings.Contains(node.Name, "task:") || strings.Contains(node.Name, "run:") ||
+	isStep := node.Command || strings.Contains(node.Name, "task:") || strings.Contains(node.Name, "run:") ||
 		strings.Contains(node.Name, "cmd:") || strings.Contains(node.Name, "cmds:")
 	if status != "" && !isStep {
 		label = label + " " + status
 	}
+	if reason := skipReason(node); reason != "" {
+		label = label + " " + reason
+	}
+	label = r.withID(label, node)
 
 	// Trim label to fit viewport (prefix + branch = indentation)
 	prefixLen := colors.VisualLength(prefix + branch)
-	label = r.trimLabel(label, prefixLen)
+	label = r.trimLabel(node, label, prefixLen)
 
 	// Render this node
 	output += prefix + branch + label
 	output += "\n"
+	output += r.renderDesc(node, prefix, isLast)
 
 	// Render output lines from command execution (with proper indentation)
-	if len(node.Output) > 0 {
-		// Determine continuation character for output indentation
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
-		}
+	output += r.renderOutput(node, prefix, isLast)
+
+	// Render children
+	children := node.GetChildren()
+	if len(children) > 0 {
+		// Determine continuation character
+		continuation := r.style.continuation(isLast)
 
-		// Calculate max width of output lines for border (visual width, excluding ANSI)
-		maxWidth := 0
-		for _, outputLine := range node.Output {
-			width := colors.VisualLength(outputLine)
-			if width > maxWidth {
-				maxWidth = width
+		groups := r.groupChildren(children)
+		for j, group := range groups {
+			childIsLast := j == len(groups)-1
+			if len(group) > 1 {
+				output += r.renderCollapsed(group, prefix+continuation, childIsLast)
+				continue
 			}
+			output += r.renderStaticNode(group[0], prefix+continuation, childIsLast)
 		}
+	}
 
-		// Add top border if 2+ elements (account for spaces around content)
-		if len(node.Output) >= 2 {
-			topBorder := prefix + continuation + colors.Gray("┌"+strings.Repeat("─", maxWidth+2)+"┐") + "\n"
-			output += topBorder
-		}
+	return output
+}
+
+// renderDesc renders the node description as a dimmed line below the node.
+func (r *Renderer) renderDesc(node *Node, prefix string, isLast bool) string {
+	if node.Desc == "" {
+		return ""
+	}
+	return prefix + r.style.continuation(isLast) + colors.Gray(node.Desc) + "\n"
+}
 
-		// Add each output line with left/right borders
-		for _, outputLine := range node.Output {
-			// Pad line to max width for consistent border (using visual width)
-			currentWidth := colors.VisualLength(outputLine)
-			padding := strings.Repeat(" ", maxWidth-currentWidth)
-			paddedLine := " " + outputLine + padding + " "
-			if len(node.Output) >= 2 {
-				output += prefix + continuation + colors.Gray("│") + colors.White(paddedLine) + colors.Gray("│") + "\n"
-			} else {
-				output += prefix + continuation + colors.White(outputLine) + "\n"
+// groupChildren groups runs of collapsible children with the same status.
+// Without collapsing, every child is in a group of its own.
+func (r *Renderer) groupChildren(children []*Node) [][]*Node {
+	groups := make([][]*Node, 0, len(children))
+	for _, child := range children {
+		if r.collapse && len(groups) > 0 && r.collapsible(child) {
+			last := groups[len(groups)-1]
+			if r.collapsible(last[0]) && last[0].Status == child.Status {
+				groups[len(groups)-1] = append(last, child)
+				continue
 			}
 		}
+		groups = append(groups, []*Node{child})
+	}
+	return groups
+}
 
-		// Add bottom border if 2+ elements (account for spaces around content)
-		if len(node.Output) >= 2 {
-			bottomBorder := prefix + continuation + colors.Gray("└"+strings.Repeat("─", maxWidth+2)+"┘") + "\n"
-			output += bottomBorder
-		}
+// collapsible returns true for passed or skipped leaf nodes without visible output.
+func (r *Renderer) collapsible(node *Node) bool {
+	if node.Status != StatusPassed && node.Status != StatusSkipped {
+		return false
 	}
+	return !node.HasChildren() && !r.hasVisibleOutput(node)
+}
 
-	// Render children
-	children := node.GetChildren()
-	if len(children) > 0 {
-		// Determine continuation character
-		continuation := "│  "
-		if isLast {
-			continuation = "   "
+// renderCollapsed renders a group of nodes with the same status as a single line.
+func (r *Renderer) renderCollapsed(group []*Node, prefix string, isLast bool) string {
+	return prefix + r.style.branch(isLast) + group[0].StatusColor() + " " + colors.Gray(fmt.Sprintf("x%d", len(group))) + "\n"
+}
+
+// hasVisibleOutput returns true if the node output is rendered.
+// Output of passed nodes is folded unless all output is shown or
+// the node keeps its output.
+func (r *Renderer) hasVisibleOutput(node *Node) bool {
+	if len(node.Output) == 0 {
+		return false
+	}
+	return node.Status != StatusPassed || node.KeepOutput || r.showAllOutput
+}
+
+// skipReason returns why a skipped node didn't run, e.g. `(skipped: if false)`.
+func skipReason(node *Node) string {
+	reason := node.GetSkipReason()
+	if reason == "" {
+		return ""
+	}
+	return colors.Gray("(skipped: " + reason + ")")
+}
+
+// renderOutput renders captured command output below a node, boxed if it spans multiple lines.
+// Output of passed nodes is folded unless all output is shown.
+func (r *Renderer) renderOutput(node *Node, prefix string, isLast bool) string {
+	if !r.hasVisibleOutput(node) {
+		return ""
+	}
+
+	output := ""
+	style := r.style
+
+	// Determine continuation character for output indentation
+	continuation := style.continuation(isLast)
+
+	// Calculate max width of output lines for border (visual width, excluding ANSI)
+	maxWidth := 0
+	for _, outputLine := range node.Output {
+		width := colors.VisualLength(outputLine)
+		if width > maxWidth {
+			maxWidth = width
 		}
+	}
+
+	// Add top border if 2+ elements (account for spaces around content)
+	if len(node.Output) >= 2 {
+		topBorder := prefix + continuation + colors.Gray(style.TopLeft+strings.Repeat(style.Horizontal, maxWidth+2)+style.TopRight) + "\n"
+		output += topBorder
+	}
 
-		for j, child := range children {
-			childIsLast := j == len(children)-1
-			output += r.renderStaticNode(child, prefix+continuation, childIsLast)
+	// Add each output line with left/right borders
+	for _, outputLine := range node.Output {
+		// Pad line to max width for consistent border (using visual width)
+		currentWidth := colors.VisualLength(outputLine)
+		padding := strings.Repeat(" ", maxWidth-currentWidth)
+		paddedLine := " " + outputLine + padding + " "
+		if len(node.Output) >= 2 {
+			output += prefix + continuation + colors.Gray(style.Vertical) + colors.White(paddedLine) + colors.Gray(style.Vertical) + "\n"
+		} else {
+			output += prefix + continuation + colors.White(outputLine) + "\n"
 		}
 	}
 
+	// Add bottom border if 2+ elements (account for spaces around content)
+	if len(node.Output) >= 2 {
+		bottomBorder := prefix + continuation + colors.Gray(style.BottomLeft+strings.Repeat(style.Horizontal, maxWidth+2)+style.BottomRight) + "\n"
+		output += bottomBorder
+	}
+
 	return output
 }
 
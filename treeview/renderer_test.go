@@ -0,0 +1,251 @@
+package treeview
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titpetric/atkins/colors"
+)
+
+func newTestTree() *Node {
+	root := NewNode("pipeline")
+	build := NewNode("build")
+	build.AddChild(NewNode("run: go build"))
+	test := NewNode("test")
+	step := NewNode("run: go test")
+	step.SetOutput([]string{"ok pkg/a", "ok pkg/b"})
+	test.AddChild(step)
+	root.AddChildren(build, test)
+	return root
+}
+
+func TestRenderer_ASCIIStyle(t *testing.T) {
+	r := NewRenderer()
+	r.SetTreeStyle(ASCIIStyle)
+
+	lines := strings.Split(colors.StripANSI(r.RenderStatic(newTestTree())), "\n")
+
+	assert.Equal(t, "pipeline", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "|- build"))
+	assert.True(t, strings.HasPrefix(lines[2], "|  \\- run: go build"))
+	assert.True(t, strings.HasPrefix(lines[3], "\\- test"))
+	assert.True(t, strings.HasPrefix(lines[4], "   \\- run: go test"))
+	assert.Equal(t, "      +----------+", lines[5])
+	assert.Equal(t, "      | ok pkg/a |", lines[6])
+
+	output := r.Render(newTestTree())
+	assert.NotContains(t, output, "├")
+	assert.NotContains(t, output, "└")
+	assert.NotContains(t, output, "│")
+}
+
+func TestRenderer_UnicodeStyle(t *testing.T) {
+	r := NewRenderer()
+	r.SetTreeStyle(UnicodeStyle)
+
+	lines := strings.Split(colors.StripANSI(r.RenderStatic(newTestTree())), "\n")
+
+	assert.True(t, strings.HasPrefix(lines[1], "├─ build"))
+	assert.True(t, strings.HasPrefix(lines[2], "│  └─ run: go build"))
+	assert.True(t, strings.HasPrefix(lines[3], "└─ test"))
+}
+
+func TestRenderer_ShowIDs(t *testing.T) {
+	tree := newTestTree()
+	build := tree.GetChildren()[0]
+	build.ID = "jobs.build"
+	build.GetChildren()[0].ID = "jobs.build.steps.0"
+
+	r := NewRenderer()
+	output := colors.StripANSI(r.RenderStatic(tree))
+	assert.NotContains(t, output, "jobs.build")
+
+	r.SetShowIDs(true)
+	lines := strings.Split(colors.StripANSI(r.RenderStatic(tree)), "\n")
+	assert.True(t, strings.HasSuffix(lines[1], " jobs.build"))
+	assert.Contains(t, lines[2], "run: go build jobs.build.steps.0")
+	assert.NotContains(t, lines[3], "jobs.")
+}
+
+func TestRenderer_SummaryProgressBar(t *testing.T) {
+	loop := NewNode("run: go test ${{ pkg }}")
+	loop.Summarize = true
+	for i := 0; i < 10; i++ {
+		child := NewNode("iteration")
+		if i < 4 {
+			child.SetStatus(StatusPassed)
+		}
+		loop.AddChild(child)
+	}
+	root := NewNode("pipeline")
+	root.AddChild(loop)
+
+	r := NewRenderer()
+
+	loop.SetStatus(StatusRunning)
+	output := colors.StripANSI(r.Render(root))
+	assert.Contains(t, output, "[####------] 4/10")
+
+	loop.SetStatus(StatusPassed)
+	output = colors.StripANSI(r.Render(root))
+	assert.Contains(t, output, "(4/10)")
+	assert.NotContains(t, output, "[#")
+}
+
+func TestRenderer_FoldPassedOutput(t *testing.T) {
+	tree := NewNode("pipeline")
+	job := NewNode("test")
+	passed := NewNode("run: go test ./a")
+	passed.SetOutput([]string{"ok pkg/a"})
+	passed.SetStatus(StatusPassed)
+	failed := NewNode("run: go test ./b")
+	failed.SetOutput([]string{"FAIL pkg/b"})
+	failed.SetStatus(StatusFailed)
+	job.AddChildren(passed, failed)
+	tree.AddChild(job)
+
+	r := NewRenderer()
+	for _, output := range []string{r.RenderStatic(tree), r.Render(tree)} {
+		output = colors.StripANSI(output)
+		assert.NotContains(t, output, "ok pkg/a")
+		assert.Contains(t, output, "FAIL pkg/b")
+	}
+
+	r.SetShowAllOutput(true)
+	output := colors.StripANSI(r.RenderStatic(tree))
+	assert.Contains(t, output, "ok pkg/a")
+	assert.Contains(t, output, "FAIL pkg/b")
+}
+
+func TestRenderer_Collapse(t *testing.T) {
+	tree := NewNode("pipeline")
+	job := NewNode("test")
+	for i := 0; i < 47; i++ {
+		node := NewNode(fmt.Sprintf("run: echo %d", i))
+		node.SetStatus(StatusPassed)
+		job.AddChild(node)
+	}
+	failed := NewNode("run: exit 1")
+	failed.SetStatus(StatusFailed)
+	job.AddChild(failed)
+	for i := 0; i < 3; i++ {
+		node := NewNode(fmt.Sprintf("run: echo skipped %d", i))
+		node.SetStatus(StatusSkipped)
+		job.AddChild(node)
+	}
+	tree.AddChild(job)
+
+	r := NewRenderer()
+	r.SetCollapse(true)
+	for _, output := range []string{r.RenderStatic(tree), r.Render(tree)} {
+		output = colors.StripANSI(output)
+		assert.Contains(t, output, "├─ ✓ x47\n")
+		assert.Contains(t, output, "run: exit 1")
+		assert.Contains(t, output, "└─ ⊘ x3\n")
+		assert.NotContains(t, output, "run: echo 0")
+		assert.Equal(t, 5, strings.Count(output, "\n"))
+	}
+
+	r.SetCollapse(false)
+	output := colors.StripANSI(r.RenderStatic(tree))
+	assert.Contains(t, output, "run: echo 0")
+	assert.NotContains(t, output, "x47")
+}
+
+func TestRenderer_SkipReason(t *testing.T) {
+	tree := NewNode("pipeline")
+	job := NewNode("deploy")
+	conditional := NewNode("run: deploy")
+	conditional.SetStatus(StatusSkipped)
+	conditional.SetIf("env == 'prod'")
+	unchanged := NewNode("run: build")
+	unchanged.SetStatus(StatusSkipped)
+	unchanged.SetSkipReason("unchanged")
+	passed := NewNode("run: test")
+	passed.SetIf("true")
+	passed.SetStatus(StatusPassed)
+	job.AddChildren(conditional, unchanged, passed)
+	tree.AddChild(job)
+
+	r := NewRenderer()
+	for _, output := range []string{r.RenderStatic(tree), r.Render(tree)} {
+		lines := strings.Split(colors.StripANSI(output), "\n")
+		assert.Contains(t, lines[2], "run: deploy")
+		assert.Contains(t, lines[2], "(skipped: if env == 'prod')")
+		assert.Contains(t, lines[3], "(skipped: unchanged)")
+		assert.NotContains(t, lines[4], "skipped")
+	}
+}
+
+func TestRenderer_Desc(t *testing.T) {
+	tree := NewNode("pipeline")
+	build := NewNode("build")
+	build.Desc = "Build the binary"
+	build.AddChild(NewNode("run: go build"))
+	lint := NewNode("lint")
+	lint.Desc = "Lint the code"
+	lint.AddChild(NewNode("run: go vet"))
+	tree.AddChildren(build, lint)
+
+	r := NewRenderer()
+	r.SetTreeStyle(UnicodeStyle)
+	for _, output := range []string{r.RenderStatic(tree), r.Render(tree)} {
+		lines := strings.Split(colors.StripANSI(output), "\n")
+		assert.Contains(t, lines[1], "build")
+		assert.NotContains(t, lines[1], "Build the binary")
+		assert.Equal(t, "│  Build the binary", lines[2])
+		assert.Contains(t, lines[3], "│  └─ run: go build")
+		assert.Equal(t, "   Lint the code", lines[5])
+		assert.Contains(t, lines[6], "   └─ run: go vet")
+	}
+}
+
+func TestRenderer_Spinner(t *testing.T) {
+	tree := NewNode("pipeline")
+	build := NewNode("build")
+	build.SetStatus(StatusRunning)
+	done := NewNode("done")
+	done.SetStatus(StatusPassed)
+	tree.AddChildren(build, done)
+
+	r := NewRenderer()
+	r.SetTreeStyle(ASCIIStyle)
+
+	// Without a spinner the running status is static
+	lines := strings.Split(colors.StripANSI(r.Render(tree)), "\n")
+	assert.Equal(t, "|- build ●", lines[1])
+
+	r.SetSpinner(LineSpinner)
+	for _, frame := range []string{"-", "\\", "|", "/", "-"} {
+		lines := strings.Split(colors.StripANSI(r.Render(tree)), "\n")
+		assert.Equal(t, "|- build "+frame, lines[1])
+		assert.Equal(t, "\\- done ✓", lines[2])
+		r.NextFrame()
+	}
+}
+
+func TestRenderer_CmdStatus(t *testing.T) {
+	root := NewNode("pipeline")
+	job := NewNode("default")
+	step := NewNode("cmds: <2 commands>")
+	first, second := NewCmdNode("echo a"), NewCmdNode("exit 1")
+	first.SetStatus(StatusPassed)
+	second.SetStatus(StatusFailed)
+	step.AddChildren(first, second)
+	job.AddChild(step)
+	root.AddChild(job)
+
+	// Commands of a run keep their status in the final tree
+	output := colors.StripANSI(NewRenderer().RenderStatic(root))
+	assert.Contains(t, output, "echo a ✓\n")
+	assert.Contains(t, output, "exit 1 ✗\n")
+
+	// Commands listed with --show-commands don't have one
+	first.Command, second.Command = true, true
+	output = colors.StripANSI(NewRenderer().RenderStatic(root))
+	assert.Contains(t, output, "echo a\n")
+	assert.Contains(t, output, "exit 1\n")
+}
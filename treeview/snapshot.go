@@ -0,0 +1,67 @@
+package treeview
+
+// Snapshot records the shape and status of a subtree so it can be restored,
+// e.g. to reset a job's nodes to pending before it's retried.
+type Snapshot struct {
+	nodes []snapshotNode
+}
+
+type snapshotNode struct {
+	node     *Node
+	name     string
+	status   Status
+	deferred bool
+	children []*Node
+}
+
+// NewSnapshot captures the current state of the node and its descendants.
+func NewSnapshot(root *Node) *Snapshot {
+	s := &Snapshot{}
+	s.capture(root)
+	return s
+}
+
+func (s *Snapshot) capture(node *Node) {
+	if node == nil {
+		return
+	}
+
+	node.mu.Lock()
+	children := make([]*Node, len(node.Children))
+	copy(children, node.Children)
+	s.nodes = append(s.nodes, snapshotNode{
+		node:     node,
+		name:     node.Name,
+		status:   node.Status,
+		deferred: node.Deferred,
+		children: children,
+	})
+	node.mu.Unlock()
+
+	for _, child := range children {
+		s.capture(child)
+	}
+}
+
+// Restore resets every captured node to its recorded name, status and
+// children, clearing any labels, output, stats and timing collected since
+// the snapshot was taken.
+func (s *Snapshot) Restore() {
+	for _, entry := range s.nodes {
+		node := entry.node
+		node.mu.Lock()
+		node.Name = entry.name
+		node.Status = entry.status
+		node.Deferred = entry.deferred
+		node.Children = make([]*Node, len(entry.children))
+		copy(node.Children, entry.children)
+		node.Output = nil
+		node.KeepOutput = false
+		node.Stats = ""
+		node.If = ""
+		node.SkipReason = ""
+		node.StartOffset = 0
+		node.Duration = 0
+		node.mu.Unlock()
+	}
+}
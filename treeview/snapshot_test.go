@@ -0,0 +1,32 @@
+package treeview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot_Restore(t *testing.T) {
+	root := NewNode("job")
+	step := NewNode("echo building")
+	root.AddChild(step)
+
+	snapshot := NewSnapshot(root)
+
+	// A run labels the step, collects output and stats and adds a child
+	step.Name = "building"
+	step.SetStatus(StatusFailed)
+	step.SetOutput([]string{"line"})
+	step.SetStats("min 0.1s, med 0.2s, max 0.3s")
+	step.AddChild(NewNode("iteration"))
+	root.SetStatus(StatusFailed)
+
+	snapshot.Restore()
+
+	assert.Equal(t, "echo building", step.Name)
+	assert.Equal(t, StatusPending, step.Status)
+	assert.Empty(t, step.Output)
+	assert.Empty(t, step.Stats)
+	assert.Empty(t, step.Children)
+	assert.Equal(t, StatusPending, root.Status)
+}
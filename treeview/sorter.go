@@ -1,6 +1,9 @@
 package treeview
 
-import "slices"
+import (
+	"maps"
+	"slices"
+)
 
 // SortJobsByDepth sorts job names by ':' depth, then alphabetically.
 // Depth is determined by the count of ':' separators in the job name.
@@ -67,8 +70,8 @@ func SortByOrder(jobSet map[string]bool, orderList []string) []string {
 		}
 	}
 
-	// Add any remaining jobs from the set not in orderList
-	for jobName := range jobSet {
+	// Add any remaining jobs from the set not in orderList, sorted for stable output
+	for _, jobName := range SortJobsByDepth(slices.Collect(maps.Keys(jobSet))) {
 		found := false
 		for _, ordered := range result {
 			if ordered == jobName {
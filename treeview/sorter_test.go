@@ -243,3 +243,20 @@ func TestCompareByDepthThenName(t *testing.T) {
 		})
 	}
 }
+
+// TestSortByOrder_StableRemainder tests that jobs missing from the order list are appended in a stable order
+func TestSortByOrder_StableRemainder(t *testing.T) {
+	jobSet := map[string]bool{
+		"build":        true,
+		"test:unit":    true,
+		"docker:push":  true,
+		"docker:build": true,
+		"lint":         true,
+		"release":      true,
+	}
+	expected := []string{"build", "lint", "release", "docker:build", "docker:push", "test:unit"}
+
+	for range 20 {
+		assert.Equal(t, expected, SortByOrder(jobSet, []string{"build"}))
+	}
+}
@@ -0,0 +1,58 @@
+package treeview
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Spinner holds the frames shown in turn as the status of running nodes.
+type Spinner struct {
+	Name     string
+	Frames   []string
+	Interval time.Duration // Time between frames
+}
+
+// Animated returns true if the spinner has more than one frame.
+func (s Spinner) Animated() bool {
+	return len(s.Frames) > 1
+}
+
+// Spinner styles. NoSpinner shows the static running status.
+var (
+	NoSpinner = Spinner{Name: "none"}
+
+	DotsSpinner = Spinner{
+		Name:     "dots",
+		Frames:   []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+		Interval: 80 * time.Millisecond,
+	}
+
+	LineSpinner = Spinner{
+		Name:     "line",
+		Frames:   []string{"-", "\\", "|", "/"},
+		Interval: 130 * time.Millisecond,
+	}
+
+	ArrowsSpinner = Spinner{
+		Name:     "arrows",
+		Frames:   []string{"←", "↖", "↑", "↗", "→", "↘", "↓", "↙"},
+		Interval: 100 * time.Millisecond,
+	}
+)
+
+// ParseSpinner returns the spinner by name ("dots", "line" or "arrows").
+// An empty name or "none" keeps the static running status.
+func ParseSpinner(name string) (Spinner, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return NoSpinner, nil
+	case "dots":
+		return DotsSpinner, nil
+	case "line":
+		return LineSpinner, nil
+	case "arrows":
+		return ArrowsSpinner, nil
+	}
+	return Spinner{}, fmt.Errorf("unknown spinner %q, expected none, dots, line or arrows", name)
+}
@@ -0,0 +1,21 @@
+package treeview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpinner(t *testing.T) {
+	spinner, err := ParseSpinner("")
+	assert.NoError(t, err)
+	assert.False(t, spinner.Animated(), "static status by default")
+
+	spinner, err = ParseSpinner("dots")
+	assert.NoError(t, err)
+	assert.Equal(t, DotsSpinner, spinner)
+	assert.True(t, spinner.Animated())
+
+	_, err = ParseSpinner("bounce")
+	assert.Error(t, err)
+}
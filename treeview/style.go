@@ -0,0 +1,108 @@
+package treeview
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TreeStyle holds the characters used to draw tree branches and output boxes.
+type TreeStyle struct {
+	Name string
+
+	Branch       string // Branch to a child that has siblings below it
+	LastBranch   string // Branch to the last child
+	Continuation string // Vertical line continuing past a child
+	Blank        string // Indentation below the last child
+
+	Horizontal  string
+	Vertical    string
+	TopLeft     string
+	TopRight    string
+	BottomLeft  string
+	BottomRight string
+}
+
+// Tree styles.
+var (
+	UnicodeStyle = TreeStyle{
+		Name:         "unicode",
+		Branch:       "├─ ",
+		LastBranch:   "└─ ",
+		Continuation: "│  ",
+		Blank:        "   ",
+		Horizontal:   "─",
+		Vertical:     "│",
+		TopLeft:      "┌",
+		TopRight:     "┐",
+		BottomLeft:   "└",
+		BottomRight:  "┘",
+	}
+
+	ASCIIStyle = TreeStyle{
+		Name:         "ascii",
+		Branch:       "|- ",
+		LastBranch:   "\\- ",
+		Continuation: "|  ",
+		Blank:        "   ",
+		Horizontal:   "-",
+		Vertical:     "|",
+		TopLeft:      "+",
+		TopRight:     "+",
+		BottomLeft:   "+",
+		BottomRight:  "+",
+	}
+)
+
+// ParseTreeStyle returns the tree style by name ("unicode" or "ascii").
+// An empty name or "auto" detects the style from the locale.
+func ParseTreeStyle(name string) (TreeStyle, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return DefaultTreeStyle(), nil
+	case "unicode":
+		return UnicodeStyle, nil
+	case "ascii":
+		return ASCIIStyle, nil
+	}
+	return TreeStyle{}, fmt.Errorf("unknown tree style %q, expected unicode or ascii", name)
+}
+
+// DefaultTreeStyle returns the unicode style, falling back to ascii
+// when the locale environment indicates a non-UTF8 charset.
+func DefaultTreeStyle() TreeStyle {
+	if isUTF8Locale() {
+		return UnicodeStyle
+	}
+	return ASCIIStyle
+}
+
+// isUTF8Locale checks LC_ALL, LC_CTYPE and LANG in order of precedence.
+// If none are set, the locale is assumed to support UTF-8.
+func isUTF8Locale() bool {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		value = strings.ToLower(value)
+		return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+	}
+	return true
+}
+
+// branch returns the branch characters for a child node.
+func (s TreeStyle) branch(isLast bool) string {
+	if isLast {
+		return s.LastBranch
+	}
+	return s.Branch
+}
+
+// continuation returns the indentation for the descendants of a child node.
+func (s TreeStyle) continuation(isLast bool) string {
+	if isLast {
+		return s.Blank
+	}
+	return s.Continuation
+}
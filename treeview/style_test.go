@@ -0,0 +1,44 @@
+package treeview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTreeStyle(t *testing.T) {
+	style, err := ParseTreeStyle("ascii")
+	assert.NoError(t, err)
+	assert.Equal(t, ASCIIStyle, style)
+
+	style, err = ParseTreeStyle("unicode")
+	assert.NoError(t, err)
+	assert.Equal(t, UnicodeStyle, style)
+
+	_, err = ParseTreeStyle("fancy")
+	assert.Error(t, err)
+}
+
+func TestDefaultTreeStyle_Locale(t *testing.T) {
+	tests := []struct {
+		name     string
+		lcAll    string
+		lang     string
+		expected TreeStyle
+	}{
+		{name: "utf-8 lang", lang: "en_US.UTF-8", expected: UnicodeStyle},
+		{name: "utf8 lang", lang: "C.utf8", expected: UnicodeStyle},
+		{name: "posix lang", lang: "C", expected: ASCIIStyle},
+		{name: "lc_all overrides lang", lcAll: "C", lang: "en_US.UTF-8", expected: ASCIIStyle},
+		{name: "unset locale", expected: UnicodeStyle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_CTYPE", "")
+			t.Setenv("LANG", tt.lang)
+			assert.Equal(t, tt.expected.Name, DefaultTreeStyle().Name)
+		})
+	}
+}
@@ -146,7 +146,9 @@ func trimWithANSI(s string, targetLen int) string {
 	}
 
 	// Append reset code if we were in the middle of colored text
-	result.WriteString("\033[0m")
+	if colors.Enabled() {
+		result.WriteString("\033[0m")
+	}
 
 	return result.String()
 }